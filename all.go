@@ -0,0 +1,60 @@
+package gv
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+)
+
+// Spec pairs a schema with the source it is decoded from, see ValidateAll
+type Spec struct {
+	Schema any
+	Source Source
+}
+
+// ValidateAll is a middleware factory function that validates several
+// schemas using the default instance, see Middleware.ValidateAll
+func ValidateAll(specs ...Spec) mux.MiddlewareFunc {
+	return std.ValidateAll(specs...)
+}
+
+// ValidateAll is a middleware factory function that decodes and validates
+// every spec, even when an earlier one fails, and calls the error handler once
+// with an *Error holding the field errors of all of them. Each field error
+// keeps the source it was read from. The data is retrieved with Validated
+// like the data of Validate. Specs are validated with the settings of the
+// Middleware: ValidateOptions like Strict, LimitBody, Partial, Loader and
+// Scenario are not supported, use Validate for schemas that need them
+func (m *Middleware) ValidateAll(specs ...Spec) mux.MiddlewareFunc {
+	types := make([]reflect.Type, len(specs))
+	for i, spec := range specs {
+		types[i] = reflect.TypeOf(spec.Schema)
+		m.prepareSchema(types[i], spec.Source, &validateConfig{})
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parsed := r.MultipartForm != nil
+			defer func() {
+				// Files of a multipart form parsed here are removed like
+				// Validate does
+				if !parsed && r.MultipartForm != nil {
+					r.MultipartForm.RemoveAll()
+				}
+			}()
+			var errs []*Error
+			for i, spec := range specs {
+				var err *Error
+				if r, err = m.validate(w, r, types[i], spec.Source, &validateConfig{}); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) > 0 {
+				m.errorHandler(joinErrors(errs)).ServeHTTP(w, r)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package gv_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAllOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := gv.Validated[*ParamsTestSchema](r, gv.Params)
+		assert.Equal(t, 7, params.ID)
+		data := gv.Validated[*TestSchema](r, gv.JSON)
+		assert.Equal(t, "John", data.Profile.Name)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with one middleware for both sources
+	validate := gv.ValidateAll(gv.Spec{ParamsTestSchema{}, gv.Params}, gv.Spec{TestSchema{}, gv.JSON})
+	router.Handle("/test/{id}", validate(handlerFunc)).Methods(http.MethodPost)
+
+	// Create a request with a parameter and a JSON body
+	req := httptest.NewRequest(http.MethodPost, "/test/7", strings.NewReader(`{"id":1,"profile":{"name":"John","email":"john@example.com"}}`))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateAllCollectsErrors(t *testing.T) {
+	// Create a request with an invalid parameter, an invalid header and an invalid body
+	req := httptest.NewRequest(http.MethodPost, "/test/abc", strings.NewReader(`{"id":1,"profile":{"email":"nope"}}`))
+	req.Header.Set("X-Request-ID", "not-a-uuid")
+	req.Header.Set("X-Tenant", "acme")
+
+	err := captureError(t, "/test/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.ValidateAll(
+			gv.Spec{ParamsTestSchema{}, gv.Params},
+			gv.Spec{HeaderTestSchema{}, gv.Header},
+			gv.Spec{TestSchema{}, gv.JSON},
+		)
+	})
+
+	// Verify that the error holds the errors of every source
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	assert.Equal(t, gv.Source(""), err.Source)
+	require.Len(t, err.Fields, 4)
+	assert.Equal(t, gv.Params, err.Fields[0].Source)
+	assert.Equal(t, "id", err.Fields[0].Field)
+	assert.Equal(t, gv.Header, err.Fields[1].Source)
+	assert.Equal(t, "X-Request-ID", err.Fields[1].Field)
+	assert.Equal(t, gv.JSON, err.Fields[2].Source)
+	assert.Equal(t, "profile.name", err.Fields[2].Field)
+	assert.Equal(t, gv.JSON, err.Fields[3].Source)
+	assert.Equal(t, "profile.email", err.Fields[3].Field)
+
+	// Verify that the original errors are still reachable
+	var validationErrs validator.ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+}
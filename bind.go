@@ -0,0 +1,44 @@
+package gv
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// bindKey is the context key of a Bind call. It is not zero-sized, so every
+// call gets a distinct pointer
+type bindKey[T any] struct {
+	_ byte
+}
+
+// Bind is the type-safe form of Validate for the default instance, see BindWith
+func Bind[T any](src Source, opts ...ValidateOption) (mux.MiddlewareFunc, func(*http.Request) *T) {
+	return BindWith[T](std, src, opts...)
+}
+
+// BindWith returns a middleware that decodes the source into a new T, a
+// struct type, and validates it like Validate does, along with an accessor
+// for the validated data. The data is stored under a key unique to the call,
+// so the accessor only ever sees data of type T, and a type mismatch is a
+// compile error rather than a panic at request time. The accessor returns nil
+// when the middleware was not applied to the request. The data is also
+// available with Validated[*T](r, src)
+//
+//	validate, body := gv.BindWith[CreateUser](v, gv.JSON)
+//	router.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+//		user := body(r)
+//	}).Use(validate)
+func BindWith[T any](m *Middleware, src Source, opts ...ValidateOption) (mux.MiddlewareFunc, func(*http.Request) *T) {
+	key := &bindKey[T]{}
+	var schema T
+	// Cap the slice so that appending never writes into the array of the caller
+	opts = append(opts[:len(opts):len(opts)], func(c *validateConfig) {
+		c.key = key
+	})
+	get := func(r *http.Request) *T {
+		data, _ := r.Context().Value(key).(*T)
+		return data
+	}
+	return m.Validate(schema, src, opts...), get
+}
@@ -0,0 +1,56 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	// Bind the URL parameters and the JSON body
+	validateParams, params := gv.Bind[ParamsTestSchema](gv.Params)
+	validateBody, body := gv.Bind[TestSchema](gv.JSON)
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 7, params(r).ID)
+		assert.Equal(t, "John", body(r).Profile.Name)
+
+		// Verify that the data is also available by source
+		assert.Same(t, body(r), gv.Validated[*TestSchema](r, gv.JSON))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with both middlewares
+	router.Handle("/test/{id}", validateParams(validateBody(handlerFunc))).Methods(http.MethodPost)
+
+	// Create a request with a parameter and a JSON body
+	req := httptest.NewRequest(http.MethodPost, "/test/7", strings.NewReader(`{"id":1,"profile":{"name":"John","email":"john@example.com"}}`))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestBindWithNotApplied(t *testing.T) {
+	// Bind with an instance but never apply the middleware
+	_, params := gv.BindWith[ParamsTestSchema](gv.New(), gv.Params)
+
+	// Verify that the accessor returns nil instead of panicking
+	req := httptest.NewRequest(http.MethodGet, "/test/7", nil)
+	require.NotPanics(t, func() {
+		assert.Nil(t, params(req))
+	})
+}
@@ -0,0 +1,41 @@
+package gv
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// bodySource returns the source that decodes the body of the request
+// according to its Content-Type, looking up the media type and then its
+// RFC 6839 structured syntax suffix like the one of application/vnd.api+json.
+// Parameters like charset are ignored
+func (m *Middleware) bodySource(r *http.Request) (Source, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", false
+	}
+	if src, ok := m.mediaTypes[mediaType]; ok {
+		return src, true
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		src, ok := m.mediaTypes[mediaType[i:]]
+		return src, ok
+	}
+	return "", false
+}
+
+// newMediaTypeError reports a body whose Content-Type is not supported
+func newMediaTypeError(r *http.Request) *Error {
+	contentType := r.Header.Get("Content-Type")
+	return &Error{
+		Kind:   KindMediaType,
+		Source: Body,
+		Fields: FieldErrors{{
+			Source:  Body,
+			Rule:    "mediatype",
+			Param:   contentType,
+			Message: "unsupported media type",
+		}},
+	}
+}
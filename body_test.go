@@ -0,0 +1,102 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBodyContentTypes(t *testing.T) {
+	form := url.Values{}
+	form.Add("id", "123")
+	form.Add("profile.name", "John")
+	form.Add("profile.email", "john@example.com")
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"JSON", "application/json", `{"id":123,"profile":{"name":"John","email":"john@example.com"}}`},
+		{"JSONCharset", "application/json; charset=utf-8", `{"id":123,"profile":{"name":"John","email":"john@example.com"}}`},
+		{"JSONSuffix", "application/vnd.api+json", `{"id":123,"profile":{"name":"John","email":"john@example.com"}}`},
+		{"XML", "text/xml; charset=utf-8", `<TestSchema><id>123</id><profile><name>John</name><email>john@example.com</email></profile></TestSchema>`},
+		{"XMLSuffix", "application/atom+xml", `<TestSchema><id>123</id><profile><name>John</name><email>john@example.com</email></profile></TestSchema>`},
+		{"Form", "application/x-www-form-urlencoded", form.Encode()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data := gv.Validated[*TestSchema](r, gv.Body)
+				assert.Equal(t, 123, data.ID)
+				assert.Equal(t, "John", data.Profile.Name)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with the validated handler
+			router.Handle("/test", gv.Validate(TestSchema{}, gv.Body)(handlerFunc)).Methods(http.MethodPost)
+
+			// Create a request with the body in the format of the test case
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestValidateBodyUnsupportedMediaType(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because the body is not supported
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(TestSchema{}, gv.Body)(handlerFunc)).Methods(http.MethodPost)
+
+	// Create a request with a YAML body
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("id: 123"))
+	req.Header.Set("Content-Type", "application/yaml")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that we got an unsupported media type response
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestValidateBodyError(t *testing.T) {
+	// Create a JSON body with an invalid email
+	body := `{"id":1,"profile":{"name":"John","email":"nope"}}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.Body)
+	})
+
+	// Verify that the error is reported with the source of the Content-Type
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	assert.Equal(t, gv.JSON, err.Source)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "profile.email", err.Fields[0].Field)
+}
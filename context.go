@@ -0,0 +1,50 @@
+package gv
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// requestKey is the context key of the request in the context passed to the
+// validator
+type requestKey struct{}
+
+// validationContext returns the context the schema of the request is validated
+// with. It is the context of the request, so rules registered with
+// validator.RegisterValidationCtx see the values set by earlier middleware,
+// like the authenticated user, and its cancellation. The request itself is
+// available with RequestFromContext
+func validationContext(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), requestKey{}, r)
+}
+
+// RequestFromContext returns the request being validated from the context
+// passed to validator.FuncCtx rules
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestKey{}).(*http.Request)
+	return r, ok
+}
+
+// RouteName returns the name of the mux route of the request being validated
+// from the context passed to validator.FuncCtx rules, or "" when the route
+// has no name
+func RouteName(ctx context.Context) string {
+	r, ok := RequestFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetName()
+	}
+	return ""
+}
+
+// ValidatedFromContext returns the data of a source validated by an earlier
+// middleware from the context passed to validator.FuncCtx rules, and whether
+// there is data of type T. It resolves the data like GetValidated does
+func ValidatedFromContext[T any](ctx context.Context, src Source) (T, bool) {
+	data, ok := storedValue[T](ctx, src).(T)
+	return data, ok
+}
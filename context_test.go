@@ -0,0 +1,107 @@
+package gv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantKey is the context key of the tenant set by withTenant
+type tenantKey struct{}
+
+// withTenant is an authentication middleware that stores the tenant of the request
+func withTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), tenantKey{}, r.Header.Get("X-Tenant"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ProjectParams defines a schema for the URL parameters of a project
+type ProjectParams struct {
+	Tenant string `schema:"tenant" validate:"required"`
+}
+
+// ProjectBody defines a schema whose rules read request-scoped values
+type ProjectBody struct {
+	Tenant string `json:"tenant" validate:"required,tenant"`
+	Plan   string `json:"plan" validate:"required,plan"`
+}
+
+// newContextValidator creates a validator with rules that read the request context
+func newContextValidator(t *testing.T) *validator.Validate {
+	v := validator.New()
+
+	// The tenant of the body must be the tenant of the user and of the URL
+	require.NoError(t, v.RegisterValidationCtx("tenant", func(ctx context.Context, fl validator.FieldLevel) bool {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		params, ok := gv.ValidatedFromContext[*ProjectParams](ctx, gv.Params)
+		return ok && fl.Field().String() == tenant && fl.Field().String() == params.Tenant
+	}))
+
+	// Only the admin route accepts the enterprise plan
+	require.NoError(t, v.RegisterValidationCtx("plan", func(ctx context.Context, fl validator.FieldLevel) bool {
+		return fl.Field().String() != "enterprise" || gv.RouteName(ctx) == "admin"
+	}))
+	return v
+}
+
+func TestValidateContextRules(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		route  string
+		header string
+		body   string
+		code   int
+	}{
+		{"OK", "admin", "acme", `{"tenant":"acme","plan":"enterprise"}`, http.StatusOK},
+		{"OtherTenant", "admin", "other", `{"tenant":"acme","plan":"free"}`, http.StatusBadRequest},
+		{"RouteName", "projects", "acme", `{"tenant":"acme","plan":"enterprise"}`, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create an instance with the context-aware rules
+			m := gv.New(gv.WithValidator(newContextValidator(t)))
+
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the named route behind the authentication middleware
+			router.Handle("/tenants/{tenant}/projects", withTenant(
+				m.Validate(ProjectParams{}, gv.Params)(m.Validate(ProjectBody{}, gv.JSON)(handlerFunc)),
+			)).Name(tc.route)
+
+			// Create a request for the tenant of the header
+			req := httptest.NewRequest(http.MethodPost, "/tenants/acme/projects", strings.NewReader(tc.body))
+			req.Header.Set("X-Tenant", tc.header)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+}
+
+func TestRequestFromContext(t *testing.T) {
+	// Verify that there is no request outside of validation
+	_, ok := gv.RequestFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Empty(t, gv.RouteName(context.Background()))
+	_, ok = gv.ValidatedFromContext[*ProjectParams](context.Background(), gv.Params)
+	assert.False(t, ok)
+}
@@ -0,0 +1,111 @@
+package gv
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyDefaults sets the fields of v that have a default tag and hold the
+// zero value to the value of the tag, walking nested structs and the elements
+// of slices and maps. A nil pointer is an absent field and gets the default, a
+// pointer to a zero value was explicitly sent and is kept
+func applyDefaults(v reflect.Value) {
+	walkValues(v, func(v reflect.Value) {
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		for i := range v.NumField() {
+			f, fv := v.Type().Field(i), v.Field(i)
+			def, ok := f.Tag.Lookup("default")
+			if !ok || !fv.CanSet() || !fv.IsZero() {
+				continue
+			}
+			if err := setDefault(fv, def); err != nil {
+				panic("invalid default of field " + f.Name + ": " + err.Error())
+			}
+		}
+	})
+}
+
+// checkDefaults panics when a default tag of typ, or of the types nested in
+// it, cannot be set, so that mistakes show up when the middleware is created
+// rather than when a request is served
+func checkDefaults(typ reflect.Type) {
+	walkFields(typ, func(f reflect.StructField) {
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if err := setDefault(reflect.New(f.Type).Elem(), def); err != nil {
+				panic("invalid default of field " + f.Name + ": " + err.Error())
+			}
+		}
+	})
+}
+
+// setDefault sets v to the value s. Slices take comma separated values
+func setDefault(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setDefault(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setDefault(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ListQuery defines a schema for query parameters with defaults
+type ListQuery struct {
+	Page    int           `schema:"page" default:"1" validate:"gte=1"`
+	Limit   int           `schema:"limit" default:"20" validate:"gte=1,lte=100"`
+	Sort    []string      `schema:"sort" default:"name,date"`
+	Timeout time.Duration `schema:"timeout" default:"5s"`
+}
+
+// Settings defines a nested schema with defaults
+type Settings struct {
+	Theme string `json:"theme" default:"light" validate:"oneof=light dark"`
+}
+
+// SettingsItem defines the elements of a slice with defaults
+type SettingsItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity" default:"1" validate:"gte=1"`
+}
+
+// ProfileBody defines a schema for a JSON body with defaults
+type ProfileBody struct {
+	Nickname *string        `json:"nickname" default:"anonymous" validate:"required"`
+	Retries  *int           `json:"retries" default:"3"`
+	Settings Settings       `json:"settings"`
+	Items    []SettingsItem `json:"items" validate:"dive"`
+}
+
+func TestValidateDefaultsQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  ListQuery
+	}{
+		{"Absent", "", ListQuery{Page: 1, Limit: 20, Sort: []string{"name", "date"}, Timeout: 5 * time.Second}},
+		{"Present", "page=3&sort=email", ListQuery{Page: 3, Limit: 20, Sort: []string{"email"}, Timeout: 5 * time.Second}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, &tc.want, gv.Validated[*ListQuery](r, gv.Query))
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with the validated handler
+			router.Handle("/test", gv.Validate(ListQuery{}, gv.Query)(handlerFunc))
+
+			// Create a request with the query of the case
+			req := httptest.NewRequest(http.MethodGet, "/test?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestValidateDefaultsJSON(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*ProfileBody](r, gv.JSON)
+
+		// Absent pointers get the default, explicit zero values are kept
+		require.NotNil(t, data.Nickname)
+		assert.Equal(t, "anonymous", *data.Nickname)
+		require.NotNil(t, data.Retries)
+		assert.Equal(t, 0, *data.Retries)
+
+		// Nested structs and the elements of slices get their defaults
+		assert.Equal(t, "light", data.Settings.Theme)
+		require.Len(t, data.Items, 2)
+		assert.Equal(t, 1, data.Items[0].Quantity)
+		assert.Equal(t, 5, data.Items[1].Quantity)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(ProfileBody{}, gv.JSON)(handlerFunc))
+
+	// Create a request without a nickname and with zero retries
+	body := `{"retries":0,"items":[{"name":"a"},{"name":"b","quantity":5}]}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateDefaultsInvalidTag(t *testing.T) {
+	type Invalid struct {
+		Page int `schema:"page" default:"first"`
+	}
+
+	// Verify that an invalid default is reported when the middleware is created
+	assert.PanicsWithValue(t, `invalid default of field Page: strconv.ParseInt: parsing "first": invalid syntax`, func() {
+		gv.Validate(Invalid{}, gv.Query)
+	})
+}
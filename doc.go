@@ -101,21 +101,242 @@ Here's an example demonstrating how to use gorilla-validator with different data
 		http.ListenAndServe(":8080", router)
 	}
 
+# Reporting Every Source at Once
+
+gv.ValidateAll validates several sources and calls the error handler once with the
+field errors of all of them, instead of stopping at the first failing source:
+
+	router.HandleFunc("/users/{id}", handler).Use(gv.ValidateAll(
+		gv.Spec{MultiParams{}, gv.Params},
+		gv.Spec{MultiBody{}, gv.JSON},
+	))
+
+# Several Schemas per Source
+
+Data is stored by source and schema type, so several schemas can be validated for
+one source on a route and retrieved independently with gv.Validated. Request bodies
+are buffered while they are decoded, so they can be decoded again.
+
+# Retrieving Validated Data
+
+gv.Validated and gv.MustValidated panic with a *gv.RetrievalError, naming the
+source, the expected type and the stored type, when there is no data of the
+requested type. gv.TryValidated and gv.GetValidated report it instead:
+
+	params, ok := gv.TryValidated[*Params](r, gv.Params)
+
+# Type-Safe Binding
+
+gv.Bind, or gv.BindWith for an instance, returns the middleware together with a typed
+accessor of the validated data, so a type mismatch is a compile error:
+
+	validate, body := gv.Bind[BodyJSON](gv.JSON)
+
+	router.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		data := body(r) // *BodyJSON
+	}).Methods("POST").Use(validate)
+
 # Validation Rules
 
 Validation rules are defined using struct tags with the go-playground/validator syntax.
 For a complete list of available validation rules, see:
 https://github.com/go-playground/validator
 
+Schemas are validated with the context of the request, so rules registered with
+RegisterValidationCtx see the values set by earlier middleware, like the authenticated
+user, and its cancellation. gv.RequestFromContext, gv.RouteName and gv.ValidatedFromContext
+return the request, the name of its route and the data of sources validated earlier:
+
+	v.RegisterValidationCtx("tenant", func(ctx context.Context, fl validator.FieldLevel) bool {
+		params, ok := gv.ValidatedFromContext[*Params](ctx, gv.Params)
+		return ok && fl.Field().String() == params.Tenant
+	})
+
+# Normalization
+
+The mod tag normalizes the strings of a field after decoding, before the defaults, the
+hooks and the validation rules, for every source. It walks nested structs, slices and
+maps. The modifiers are applied in order:
+
+  - trim removes leading and trailing white space
+  - lower and upper change the case
+  - squash trims and collapses runs of white space into a single space
+  - nfc and nfkc apply the Unicode normalization forms
+
+For example:
+
+	type Signup struct {
+		Email    string `json:"email" mod:"trim,lower" validate:"required,email"`
+		Username string `json:"username" mod:"nfc,squash" validate:"required"`
+	}
+
+# Default Values
+
+Fields with a default tag that are left empty after decoding get the value of the tag
+before the hooks and the validation rules run, for every source. Scalars, durations,
+types implementing encoding.TextUnmarshaler and comma separated slices are supported,
+and nested structs and the elements of slices are walked. A nil pointer is an absent
+field and gets the default, while a pointer to a zero value was sent explicitly and is
+kept:
+
+	type List struct {
+		Page    int  `schema:"page" default:"1" validate:"gte=1"`
+		Limit   int  `schema:"limit" default:"20" validate:"lte=100"`
+		Retries *int `json:"retries" default:"3"`
+	}
+
+Invalid defaults make Validate panic when the middleware is created.
+
+# Validate Methods and Hooks
+
+Rules that are easier to write in Go than in tags go in a Validate(ctx context.Context) error
+or Validate() gv.FieldErrors method of the schema. It is called after the validation rules,
+and the field errors it returns are reported together with theirs. AfterDecode and
+BeforeValidate methods normalize the data after decoding, once the mod and default tags
+are applied, and right before the rules:
+
+	func (b *Booking) Validate(ctx context.Context) error {
+		if b.Plan != "enterprise" && b.End.Sub(b.Start) > 90*24*time.Hour {
+			return gv.FieldErrors{{Field: "end", Rule: "within90days", Message: "must be within 90 days of start"}}
+		}
+		return nil
+	}
+
 # Sources
 
 The library supports the following sources for validation:
 
   - gv.Params: URL parameters from Gorilla Mux
   - gv.Query: Query string parameters
-  - gv.Form: Form data from POST requests
+  - gv.Form: Form data from POST requests, urlencoded or multipart/form-data
   - gv.JSON: JSON request body
   - gv.XML: XML request body
+  - gv.Header: request headers, named by the header tag and matched in canonical form
+  - gv.Cookie: request cookies, named by the cookie tag
+  - gv.Body: request body decoded as JSON, XML or Form depending on its Content-Type,
+    other media types are rejected with HTTP 415 (Unsupported Media Type)
+  - gv.Request: one schema whose fields are read from the places named by their in tag
+  - gv.MergePatch: JSON Merge Patch body applied to the resource returned by gv.Loader
+  - gv.JSONPatch: JSON Patch body applied to the resource returned by gv.Loader
+
+# Several Places in One Schema
+
+gv.Request reads each field from the place named by its in tag: path, query, header,
+cookie or body. The whole schema is validated at once, so cross-field rules work across
+places, and errors are reported with the source of each field:
+
+	type UpdateUser struct {
+		ID       int      `in:"path" schema:"id" validate:"required"`
+		Limit    int      `in:"query" schema:"limit" validate:"ltefield=MaxLimit"`
+		MaxLimit int      `in:"header" header:"X-Max-Limit"`
+		User     UserBody `in:"body"`
+	}
+
+	router.HandleFunc("/users/{id}", handler).Use(gv.Validate(UpdateUser{}, gv.Request))
+
+# Body Size Limits
+
+The request body is read without a limit unless one is set with gv.WithMaxBodySize,
+gv.MaxBodySize or, for a single route, gv.LimitBody:
+
+	router.HandleFunc("/uploads", upload).Use(gv.Validate(Upload{}, gv.Form, gv.LimitBody(50<<20)))
+
+Larger bodies are rejected with HTTP 413 (Content Too Large).
+
+# Strict Decoding
+
+gv.WithStrictJSON, gv.StrictJSON and, for a single route, gv.Strict make JSON
+decoding reject unknown fields and data after the JSON document. Unknown fields are
+reported with their paths and the unknown rule. For the Params, Query and Form
+sources gv.Strict overrides the IgnoreUnknownKeys setting of the schema decoder for a
+single route, rejecting every unexpected parameter or ignoring them. Keys are matched
+against the schema tags of the fields, the default alias tag of the decoder.
+
+# Partial Updates
+
+gv.Partial makes a Validate call apply the rules only to the fields present in a JSON
+body, read from the keys of the document rather than from zero values, so one schema
+serves both creating and updating a resource:
+
+	router.HandleFunc("/users/{id}", update).Methods("PATCH").Use(gv.Validate(User{}, gv.JSON, gv.Partial()))
+
+# Field Presence
+
+gv.Present and gv.Null tell whether a field was sent in the JSON body validated for a
+source, and whether it was sent as null. Paths are named by the json tags like the field
+paths of errors:
+
+	if gv.Present(r, gv.JSON, "address.city") && !gv.Null(r, gv.JSON, "address.city") {
+		// update the city
+	}
+
+gv.Optional[T] fields carry the same information in the schema. Their rules apply to
+the value when it is set and not null:
+
+	type UpdateUser struct {
+		Nickname gv.Optional[string] `json:"nickname" validate:"omitempty,min=3"`
+	}
+
+	nickname, ok := data.Nickname.Get()
+
+# Patch Documents
+
+gv.MergePatch (RFC 7396) and gv.JSONPatch (RFC 6902), also picked by gv.Body for the
+application/merge-patch+json and application/json-patch+json media types, apply the
+patch to the resource returned by gv.Loader and validate the result. The patched
+resource is retrieved with gv.Validated and the patch with gv.RawPatch and
+gv.PatchOperations. Errors of fields set by a JSON Patch operation point to it, like
+"[2].value.city". Errors of the loader are reported with the kind gv.KindLoad, or
+gv.KindNotFound when they wrap gv.ErrNotFound, and are not shown to clients:
+
+	router.HandleFunc("/users/{id}", update).Methods("PATCH").
+		Use(gv.Validate(User{}, gv.Body, gv.Loader(func(r *http.Request) (any, error) {
+			return store.User(mux.Vars(r)["id"])
+		})))
+
+# Scenarios
+
+gv.Scenario("update") makes a Validate call apply the rules of the validate.update tags
+in place of the validate tags of the same fields. Fields without a tag for the scenario
+keep their validate tag:
+
+	type User struct {
+		Name     string `json:"name" validate:"required" validate.update:"omitempty,min=2"`
+		Password string `json:"password" validate:"required,min=8"`
+	}
+
+	router.HandleFunc("/users/{id}", update).Use(gv.Validate(User{}, gv.JSON, gv.Scenario("update")))
+
+The tags of a scenario are applied by a validator of their own. Scenarios whose tags use
+custom rules get a validator configured like the one of the instance, set with
+gv.WithScenarioValidator or gv.ScenarioValidator before the routes are created.
+
+# Custom Sources
+
+Sources are decoded by functions registered per instance, the built-in sources
+included. Register a source to support another body format or to replace a built-in
+one:
+
+	v := gv.New(gv.WithSource(YAML, func(r *http.Request, dst any) error {
+		return yaml.NewDecoder(r.Body).Decode(dst)
+	}, gv.Tag("yaml"), gv.MediaTypes("application/yaml", "+yaml")))
+
+gv.Tag names the struct tag used for the field paths of errors, and gv.MediaTypes
+lets gv.Body decode the given media types with the source. gv.RegisterSource
+registers a source on the default instance.
+
+# File Uploads
+
+For multipart/form-data bodies the Form source binds file parts to
+*multipart.FileHeader and []*multipart.FileHeader fields. Files are validated with
+the filesize rule, the filetype rule that checks the type detected from the content,
+and the built-in min and max rules for the count of files:
+
+	type Upload struct {
+		Avatar *multipart.FileHeader `schema:"avatar" validate:"required,filesize=5MB,filetype=image/png image/jpeg"`
+	}
+
+The memory used while parsing is limited with gv.MultipartMemory or gv.WithMultipartMemory.
 
 # Error Handling
 
@@ -127,5 +348,37 @@ when validation fails. This behavior can be customized by using the `gv.ErrorHan
 			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
 		}
 	})
+
+The error passed to the handler is always a *gv.Error. It tells whether decoding or
+validation failed and lists every rejected field, whatever decoder or validator
+produced the failure. Field paths use the wire names of the source, i.e. the json,
+xml, schema, header or cookie tag, so a nested failure is reported as items[3].price:
+
+	var e *gv.Error
+	if errors.As(err, &e) {
+		for _, f := range e.Fields {
+			log.Printf("%s %s: %s", f.Source, f.Field, f.Message)
+		}
+	}
+
+gv.ProblemErrorHandler responds with RFC 9457 application/problem+json instead,
+listing the rejected fields in the errors member:
+
+	v := gv.New(gv.WithErrorHandler(gv.ProblemErrorHandler))
+
+# Instances
+
+The package-level functions share one default instance. To keep validators, schema
+decoders and error handlers of different services or tests apart, create an
+instance with gv.New:
+
+	v := gv.New(
+		gv.WithValidator(validator.New()),
+		gv.WithErrorHandler(myErrorHandler),
+	)
+
+	router.HandleFunc("/users/{id}", handler).Use(v.Validate(Params{}, gv.Params))
+
+Data stored by any instance is retrieved with the same gv.Validated function.
 */
 package gv
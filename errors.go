@@ -0,0 +1,402 @@
+package gv
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/schema"
+)
+
+// ErrorKind tells at which stage the request was rejected
+type ErrorKind string
+
+const (
+	// KindDecode means the input could not be decoded into the schema
+	KindDecode ErrorKind = "decode"
+	// KindValidation means the decoded schema failed validation rules
+	KindValidation ErrorKind = "validation"
+	// KindMediaType means the Content-Type of the body is not supported
+	KindMediaType ErrorKind = "media_type"
+	// KindTooLarge means the body is larger than the configured limit
+	KindTooLarge ErrorKind = "too_large"
+	// KindNotFound means the resource a patch is applied to does not exist
+	KindNotFound ErrorKind = "not_found"
+	// KindLoad means the resource a patch is applied to could not be loaded.
+	// It is an error of the server, its cause is not shown to clients
+	KindLoad ErrorKind = "load"
+)
+
+// FieldError describes a single rejected field
+type FieldError struct {
+	// Source is the source the field was read from
+	Source Source `json:"source"`
+	// Field is the path of the field, empty when the error is not bound to a field
+	Field string `json:"field,omitempty"`
+	// Rule is the validation tag or the decoding rule that failed, e.g. "required" or "type"
+	Rule string `json:"rule,omitempty"`
+	// Param is the parameter of the rule, e.g. "8" for "min=8"
+	Param string `json:"param,omitempty"`
+	// Value is the rejected value when it is known. It is not serialized so
+	// that secrets like passwords are never echoed back to clients
+	Value any `json:"-"`
+	// Message is a human readable description of the failure
+	Message string `json:"message"`
+}
+
+// FieldErrors is a list of field errors. It implements error, so decoders can
+// return it to report several fields; fields without a source get the source
+// of the decoder
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	messages := make([]string, 0, len(fe))
+	for _, f := range fe {
+		if f.Field == "" {
+			messages = append(messages, f.Message)
+		} else {
+			messages = append(messages, f.Field+": "+f.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Error is passed to the error handler for every rejected request. It
+// normalizes the errors of go-playground/validator, gorilla/schema,
+// encoding/json and encoding/xml into a single list of field errors and keeps
+// the original error reachable with errors.Unwrap
+type Error struct {
+	Kind   ErrorKind
+	Source Source
+	Fields FieldErrors
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Fields.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status code that matches the kind of the error
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindLoad:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// newError wraps err into an *Error of the given kind. Errors that are already
+// an *Error are returned as is
+func newError(kind ErrorKind, src Source, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: kind, Source: src, Fields: fieldErrors(src, err), Err: err}
+}
+
+// kindPrecedence orders error kinds from the one that decides the status of
+// joined errors first
+var kindPrecedence = []ErrorKind{KindLoad, KindNotFound, KindTooLarge, KindMediaType, KindDecode, KindValidation}
+
+// joinErrors merges the errors of several sources into one. The kind is the
+// first of kindPrecedence found, the kind of the first error otherwise, and
+// the source is only set when all errors share it
+func joinErrors(errs []*Error) *Error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	joined := &Error{Kind: errs[0].Kind, Source: errs[0].Source}
+	causes := make([]error, 0, len(errs))
+	kinds := make(map[ErrorKind]bool)
+	for _, e := range errs {
+		if e.Source != joined.Source {
+			joined.Source = ""
+		}
+		joined.Fields = append(joined.Fields, e.Fields...)
+		causes = append(causes, e)
+		kinds[e.Kind] = true
+	}
+	for _, k := range kindPrecedence {
+		if kinds[k] {
+			joined.Kind = k
+			break
+		}
+	}
+	joined.Err = errors.Join(causes...)
+	return joined
+}
+
+// newDecodeError wraps an error of a decoder, telling bodies over the size
+// limit apart from malformed ones
+func newDecodeError(src Source, err error) *Error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return newError(KindTooLarge, src, err)
+	}
+	return newError(KindDecode, src, err)
+}
+
+// newValidationError wraps an error of the validator, reporting each field
+// with the source and the path that fieldPath returns for its struct namespace
+func newValidationError(src Source, err error, schema any, fieldPath func(typ reflect.Type, ns string) (Source, string)) *Error {
+	e := newError(KindValidation, src, err)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != len(e.Fields) {
+		return e
+	}
+	typ := reflect.TypeOf(schema)
+	for i, fe := range validationErrs {
+		e.Fields[i].Source, e.Fields[i].Field = fieldPath(typ, fe.StructNamespace())
+	}
+	return e
+}
+
+// newSchemaError wraps an error of the schema decoder, filling rejected values
+// from the decoded map
+func newSchemaError(src Source, err error, values map[string][]string) *Error {
+	e := newError(KindDecode, src, err)
+	for i, f := range e.Fields {
+		e.Fields[i].Value = valuesOf(values[f.Field])
+	}
+	return e
+}
+
+// valuesOf returns the single value of a key of the schema decoder input, all
+// of its values when there are several, or nil
+func valuesOf(v []string) any {
+	switch len(v) {
+	case 0:
+		return nil
+	case 1:
+		return v[0]
+	default:
+		return v
+	}
+}
+
+// fieldErrors converts the errors of the underlying decoders and of the
+// validator into field errors
+func fieldErrors(src Source, err error) FieldErrors {
+	var (
+		validationErrs validator.ValidationErrors
+		multiErr       schema.MultiError
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		xmlSyntaxErr   *xml.SyntaxError
+		numErr         *strconv.NumError
+		maxBytesErr    *http.MaxBytesError
+		fieldErrs      FieldErrors
+	)
+	switch {
+	case errors.As(err, &fieldErrs):
+		fields := make(FieldErrors, len(fieldErrs))
+		for i, f := range fieldErrs {
+			if f.Source == "" {
+				f.Source = src
+			}
+			fields[i] = f
+		}
+		return fields
+	case errors.As(err, &maxBytesErr):
+		limit := strconv.FormatInt(maxBytesErr.Limit, 10)
+		return FieldErrors{{
+			Source:  src,
+			Rule:    "maxbytes",
+			Param:   limit,
+			Message: "body must not be larger than " + limit + " bytes",
+		}}
+	case errors.As(err, &validationErrs):
+		fields := make(FieldErrors, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Source:  src,
+				Field:   trimNamespace(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Value:   fe.Value(),
+				Message: ruleMessage(fe.Tag(), fe.Param()),
+			})
+		}
+		return fields
+	case errors.As(err, &multiErr):
+		keys := make([]string, 0, len(multiErr))
+		for k := range multiErr {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := make(FieldErrors, 0, len(keys))
+		for _, k := range keys {
+			fields = append(fields, schemaFieldError(src, k, multiErr[k]))
+		}
+		return fields
+	case errors.As(err, &syntaxErr):
+		return FieldErrors{{
+			Source:  src,
+			Rule:    "syntax",
+			Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset),
+		}}
+	case errors.As(err, &typeErr):
+		kind := jsonKind(typeErr.Type)
+		return FieldErrors{{
+			Source:  src,
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   kind,
+			Value:   typeErr.Value,
+			Message: "must be of type " + kind,
+		}}
+	case errors.As(err, &xmlSyntaxErr):
+		return FieldErrors{{
+			Source:  src,
+			Rule:    "syntax",
+			Message: fmt.Sprintf("malformed XML at line %d: %s", xmlSyntaxErr.Line, xmlSyntaxErr.Msg),
+		}}
+	case errors.As(err, &numErr):
+		return FieldErrors{{
+			Source:  src,
+			Rule:    "type",
+			Value:   numErr.Num,
+			Message: "must be a valid number",
+		}}
+	case errors.Is(err, io.EOF):
+		return FieldErrors{{Source: src, Rule: "required", Message: "body is empty"}}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return FieldErrors{{Source: src, Rule: "syntax", Message: "body is truncated"}}
+	default:
+		return FieldErrors{{Source: src, Message: err.Error()}}
+	}
+}
+
+// jsonKind returns the JSON type of the values decoded into t, so that the Go
+// types of the schema are not shown to clients
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) && !reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "value"
+	}
+}
+
+// schemaFieldError converts a single error of the schema decoder
+func schemaFieldError(src Source, key string, err error) FieldError {
+	var (
+		conversionErr schema.ConversionError
+		emptyErr      schema.EmptyFieldError
+		unknownErr    schema.UnknownKeyError
+	)
+	switch {
+	case errors.As(err, &conversionErr):
+		typ := conversionErr.Type.String()
+		return FieldError{Source: src, Field: key, Rule: "type", Param: typ, Message: "must be of type " + typ}
+	case errors.As(err, &emptyErr):
+		return FieldError{Source: src, Field: key, Rule: "required", Message: ruleMessage("required", "")}
+	case errors.As(err, &unknownErr):
+		return FieldError{Source: src, Field: key, Rule: "unknown", Message: "is not allowed"}
+	default:
+		return FieldError{Source: src, Field: key, Message: err.Error()}
+	}
+}
+
+// trimNamespace strips the name of the top-level struct from a validator namespace
+func trimNamespace(ns string) string {
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// ruleMessages holds messages of the most common validation rules, %s is replaced by the rule parameter
+var ruleMessages = map[string]string{
+	"required":   "is required",
+	"email":      "must be a valid email address",
+	"url":        "must be a valid URL",
+	"uri":        "must be a valid URI",
+	"uuid":       "must be a valid UUID",
+	"numeric":    "must be numeric",
+	"alpha":      "must contain only letters",
+	"alphanum":   "must contain only letters and digits",
+	"boolean":    "must be a boolean",
+	"len":        "must have length %s",
+	"min":        "must be at least %s",
+	"max":        "must be at most %s",
+	"eq":         "must be equal to %s",
+	"ne":         "must not be equal to %s",
+	"gt":         "must be greater than %s",
+	"gte":        "must be greater than or equal to %s",
+	"lt":         "must be less than %s",
+	"lte":        "must be less than or equal to %s",
+	"oneof":      "must be one of: %s",
+	"eqfield":    "must be equal to %s",
+	"nefield":    "must not be equal to %s",
+	"gtfield":    "must be greater than %s",
+	"gtefield":   "must be greater than or equal to %s",
+	"ltfield":    "must be less than %s",
+	"ltefield":   "must be less than or equal to %s",
+	"contains":   "must contain %s",
+	"excludes":   "must not contain %s",
+	"startswith": "must start with %s",
+	"endswith":   "must end with %s",
+	"filesize":   "must not be larger than %s",
+	"filetype":   "must be one of the file types: %s",
+}
+
+// ruleMessage returns a human readable message for a failed rule
+func ruleMessage(tag, param string) string {
+	if msg, ok := ruleMessages[tag]; ok {
+		if strings.Contains(msg, "%s") {
+			return fmt.Sprintf(msg, param)
+		}
+		return msg
+	}
+	if param != "" {
+		return fmt.Sprintf("failed on the '%s=%s' rule", tag, param)
+	}
+	return fmt.Sprintf("failed on the '%s' rule", tag)
+}
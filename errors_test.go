@@ -0,0 +1,210 @@
+package gv_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureError serves the request on a route guarded by the middleware that
+// build returns for a fresh instance created with opts, and returns the error
+// passed to the error handler of that instance
+func captureError(t *testing.T, route string, req *http.Request, build func(m *gv.Middleware) mux.MiddlewareFunc, opts ...gv.Option) *gv.Error {
+	t.Helper()
+
+	// Create an instance whose error handler stores the error
+	var got *gv.Error
+	m := gv.New(append(opts, gv.WithErrorHandler(func(err error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			require.True(t, errors.As(err, &got))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))...)
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because validation will fail
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the validated handler
+	router.Handle(route, build(m)(handlerFunc))
+
+	// Let the router handle the request
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.NotNil(t, got)
+	return got
+}
+
+func TestErrorValidation(t *testing.T) {
+	// Create a JSON body with a missing name and an invalid email
+	body := `{"id":1,"profile":{"email":"nope"}}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.JSON)
+	})
+
+	// Verify the kind and the field errors
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	assert.Equal(t, gv.JSON, err.Source)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, "required", err.Fields[0].Rule)
+	assert.Equal(t, "is required", err.Fields[0].Message)
+	assert.Equal(t, "email", err.Fields[1].Rule)
+	assert.Equal(t, "nope", err.Fields[1].Value)
+	assert.Equal(t, gv.JSON, err.Fields[1].Source)
+
+	// Verify that the original error is still reachable
+	var validationErrs validator.ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+}
+
+func TestErrorSchemaConversion(t *testing.T) {
+	// Create a request with a non-integer ID
+	req := httptest.NewRequest(http.MethodGet, "/test?id=abc&profile.name=John&profile.email=john@example.com", nil)
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.Query)
+	})
+
+	// Verify the kind and the field error
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, gv.FieldError{
+		Source:  gv.Query,
+		Field:   "id",
+		Rule:    "type",
+		Param:   "int",
+		Value:   "abc",
+		Message: "must be of type int",
+	}, err.Fields[0])
+}
+
+func TestErrorJSONType(t *testing.T) {
+	// Create a JSON body with a string where a number is expected
+	body := `{"id":"abc","profile":{"name":"John","email":"john@example.com"}}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.JSON)
+	})
+
+	// Verify the kind and the field error
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "id", err.Fields[0].Field)
+	assert.Equal(t, "type", err.Fields[0].Rule)
+	assert.Equal(t, "string", err.Fields[0].Value)
+	assert.Equal(t, "integer", err.Fields[0].Param)
+
+	var typeErr *json.UnmarshalTypeError
+	assert.True(t, errors.As(err, &typeErr))
+}
+
+func TestErrorJSONTypeKinds(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		body  string
+		field string
+		kind  string
+	}{
+		{"Object", `[1]`, "", "object"},
+		{"NestedObject", `{"id":1,"profile":"x"}`, "profile", "object"},
+		{"String", `{"id":1,"profile":{"name":1}}`, "profile.name", "string"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tc.body))
+
+			err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+				return m.Validate(TestSchema{}, gv.JSON)
+			})
+
+			// Verify that the JSON type is reported instead of the Go type
+			require.Len(t, err.Fields, 1)
+			assert.Equal(t, tc.field, err.Fields[0].Field)
+			assert.Equal(t, tc.kind, err.Fields[0].Param)
+			assert.Equal(t, "must be of type "+tc.kind, err.Fields[0].Message)
+		})
+	}
+}
+
+func TestErrorJSONSyntax(t *testing.T) {
+	// Create an invalid JSON body
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{invalid json}`))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.JSON)
+	})
+
+	// Verify the kind and that the error is not bound to a field
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "", err.Fields[0].Field)
+	assert.Equal(t, "syntax", err.Fields[0].Rule)
+}
+
+func TestErrorXMLSyntax(t *testing.T) {
+	// Create an unterminated XML body
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`<TestSchema><id>1</id>`))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.XML)
+	})
+
+	// Verify the kind and the rule
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "syntax", err.Fields[0].Rule)
+}
+
+// OrderSchema uses different wire names for JSON and for query parameters
+type OrderSchema struct {
+	CustomerEmail string      `json:"customerEmail" schema:"customer_email" validate:"required,email"`
+	Items         []OrderItem `json:"items" schema:"items" validate:"dive"`
+}
+
+type OrderItem struct {
+	Price int `json:"price" schema:"price" validate:"gt=0"`
+}
+
+func TestErrorFieldPathJSON(t *testing.T) {
+	// Create a JSON body with an invalid email and an invalid nested price
+	body := `{"customerEmail":"nope","items":[{"price":1},{"price":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(OrderSchema{}, gv.JSON)
+	})
+
+	// Verify that the paths use the json tags
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, "customerEmail", err.Fields[0].Field)
+	assert.Equal(t, "items[1].price", err.Fields[1].Field)
+}
+
+func TestErrorFieldPathQuery(t *testing.T) {
+	// Create a request with an invalid email using the same schema as the JSON test
+	req := httptest.NewRequest(http.MethodGet, "/test?customer_email=nope", nil)
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(OrderSchema{}, gv.Query)
+	})
+
+	// Verify that the path uses the schema tag
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "customer_email", err.Fields[0].Field)
+}
@@ -0,0 +1,67 @@
+package gv
+
+import (
+	"context"
+)
+
+// AfterDecoder is implemented by schemas that normalize their data once it is
+// decoded, after the mod and default tags are applied. An error rejects the
+// request like a decoding error
+type AfterDecoder interface {
+	AfterDecode(ctx context.Context) error
+}
+
+// BeforeValidator is implemented by schemas that prepare their data right
+// before the validation rules are applied. An error rejects the request like
+// a failed rule
+type BeforeValidator interface {
+	BeforeValidate(ctx context.Context) error
+}
+
+// ContextValidator is implemented by schemas with rules that are easier to
+// write in Go than in tags. Validate is called after the validation rules,
+// even when they failed, and the field errors it returns are merged with
+// theirs. Other errors are reported as a single field error
+type ContextValidator interface {
+	Validate(ctx context.Context) error
+}
+
+// FieldsValidator is the form of ContextValidator for rules that do not need
+// the context of the request
+type FieldsValidator interface {
+	Validate() FieldErrors
+}
+
+// afterDecode calls the AfterDecode hook of the schema
+func afterDecode(ctx context.Context, schema any) error {
+	if h, ok := schema.(AfterDecoder); ok {
+		return h.AfterDecode(ctx)
+	}
+	return nil
+}
+
+// beforeValidate calls the BeforeValidate hook of the schema
+func beforeValidate(ctx context.Context, schema any) error {
+	if h, ok := schema.(BeforeValidator); ok {
+		return h.BeforeValidate(ctx)
+	}
+	return nil
+}
+
+// validateSchema calls the Validate method of the schema. Empty field errors
+// are not an error
+func validateSchema(ctx context.Context, schema any) error {
+	var err error
+	switch v := schema.(type) {
+	case ContextValidator:
+		err = v.Validate(ctx)
+	case FieldsValidator:
+		if fields := v.Validate(); len(fields) > 0 {
+			err = fields
+		}
+	}
+	if fields, ok := err.(FieldErrors); ok && len(fields) == 0 {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package gv_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Booking defines a schema with hooks and a rule written in Go
+type Booking struct {
+	Email string    `json:"email" validate:"required,email"`
+	Plan  string    `json:"plan" validate:"required,oneof=free enterprise"`
+	Start time.Time `json:"start" validate:"required"`
+	End   time.Time `json:"end" validate:"required,gtfield=Start"`
+	steps []string
+}
+
+// AfterDecode normalizes the email before the rules are applied
+func (b *Booking) AfterDecode(ctx context.Context) error {
+	b.steps = append(b.steps, "after decode")
+	b.Email = strings.TrimSpace(b.Email)
+	return nil
+}
+
+// BeforeValidate rejects bookings that were cancelled while being decoded
+func (b *Booking) BeforeValidate(ctx context.Context) error {
+	b.steps = append(b.steps, "before validate")
+	return ctx.Err()
+}
+
+// Validate limits the length of bookings unless the plan is enterprise
+func (b *Booking) Validate(ctx context.Context) error {
+	if b.Plan != "enterprise" && b.End.Sub(b.Start) > 90*24*time.Hour {
+		return gv.FieldErrors{{Field: "end", Rule: "within90days", Message: "must be within 90 days of start"}}
+	}
+	return nil
+}
+
+// Coupon defines a schema validated by a Validate method without the context
+type Coupon struct {
+	Code string `json:"code"`
+}
+
+// Validate accepts upper case codes only
+func (c Coupon) Validate() gv.FieldErrors {
+	if c.Code != strings.ToUpper(c.Code) {
+		return gv.FieldErrors{{Field: "code", Rule: "uppercase", Message: "must be upper case"}}
+	}
+	return nil
+}
+
+// Refund defines a schema whose hook fails with a plain error
+type Refund struct {
+	Amount int `json:"amount"`
+}
+
+// AfterDecode rejects every refund
+func (Refund) AfterDecode(ctx context.Context) error {
+	return errors.New("refunds are disabled")
+}
+
+func TestValidateHooksOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*Booking](r, gv.JSON)
+		assert.Equal(t, "john@example.com", data.Email)
+		assert.Equal(t, []string{"after decode", "before validate"}, data.steps)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(Booking{}, gv.JSON)(handlerFunc))
+
+	// Create a request with a padded email and a long enterprise booking
+	body := `{"email":" john@example.com ","plan":"enterprise","start":"2025-01-01T00:00:00Z","end":"2025-12-01T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateMethodMergesErrors(t *testing.T) {
+	// Create a request with an invalid email and a long free booking
+	body := `{"email":"invalid","plan":"free","start":"2025-01-01T00:00:00Z","end":"2025-12-01T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(Booking{}, gv.JSON)
+	})
+
+	// Verify that the errors of the rules and of the method are reported together
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	assert.Equal(t, gv.JSON, err.Source)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, "email", err.Fields[0].Field)
+	assert.Equal(t, "email", err.Fields[0].Rule)
+	assert.Equal(t, gv.FieldError{Source: gv.JSON, Field: "end", Rule: "within90days", Message: "must be within 90 days of start"}, err.Fields[1])
+}
+
+func TestValidateFieldsMethod(t *testing.T) {
+	// Create a request with a lower case code
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"code":"spring"}`))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(Coupon{}, gv.JSON)
+	})
+
+	// Verify that the error of the method is reported
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "code", err.Fields[0].Field)
+	assert.Equal(t, "uppercase", err.Fields[0].Rule)
+}
+
+func TestAfterDecodeError(t *testing.T) {
+	// Create a request for the disabled refunds
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"amount":10}`))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(Refund{}, gv.JSON)
+	})
+
+	// Verify that the error of the hook is reported as a decoding error
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "refunds are disabled", err.Fields[0].Message)
+}
@@ -0,0 +1,82 @@
+package gv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// modifiers holds the normalizations of the mod tag
+var modifiers = map[string]func(string) string{
+	// trim removes leading and trailing white space
+	"trim": strings.TrimSpace,
+	// lower and upper change the case
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// squash trims and collapses runs of white space into a single space
+	"squash": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	// nfc and nfkc apply the Unicode normalization forms
+	"nfc":  norm.NFC.String,
+	"nfkc": norm.NFKC.String,
+}
+
+// parseModifiers returns the normalizations of a mod tag in their order
+func parseModifiers(tag string) ([]func(string) string, error) {
+	var mods []func(string) string
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		mod, ok := modifiers[name]
+		if !ok {
+			return nil, errors.New("unknown modifier " + name)
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// applyModifiers normalizes the strings of the fields of v that have a mod
+// tag, walking nested structs and the elements of slices and maps
+func applyModifiers(v reflect.Value) {
+	walkValues(v, func(v reflect.Value) {
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		for i := range v.NumField() {
+			f, fv := v.Type().Field(i), v.Field(i)
+			tag, ok := f.Tag.Lookup("mod")
+			if !ok || !fv.CanSet() {
+				continue
+			}
+			mods, err := parseModifiers(tag)
+			if err != nil {
+				panic("invalid mod of field " + f.Name + ": " + err.Error())
+			}
+			walkValues(fv, func(v reflect.Value) {
+				if v.Kind() == reflect.String {
+					s := v.String()
+					for _, mod := range mods {
+						s = mod(s)
+					}
+					v.SetString(s)
+				}
+			})
+		}
+	})
+}
+
+// checkModifiers panics when a mod tag of typ, or of the types nested in it,
+// names an unknown modifier
+func checkModifiers(typ reflect.Type) {
+	walkFields(typ, func(f reflect.StructField) {
+		if tag, ok := f.Tag.Lookup("mod"); ok {
+			if _, err := parseModifiers(tag); err != nil {
+				panic("invalid mod of field " + f.Name + ": " + err.Error())
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SignupAddress defines a nested schema with modifiers
+type SignupAddress struct {
+	City string `json:"city" mod:"squash" validate:"required"`
+}
+
+// SignupBody defines a schema for a JSON body with modifiers
+type SignupBody struct {
+	Email    string            `json:"email" mod:"trim,lower" validate:"required,email"`
+	Username string            `json:"username" mod:"nfc,squash" validate:"required"`
+	Tags     []string          `json:"tags" mod:"trim,lower"`
+	Labels   map[string]string `json:"labels" mod:"upper"`
+	Address  *SignupAddress    `json:"address"`
+}
+
+// SearchQuery defines a schema for query parameters with a modifier and a default
+type SearchQuery struct {
+	Sort string `schema:"sort" mod:"trim" default:"name"`
+}
+
+func TestValidateModifiersJSON(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*SignupBody](r, gv.JSON)
+		assert.Equal(t, "foo@example.com", data.Email)
+		assert.Equal(t, "Caf\u00e9 Owner", data.Username)
+		assert.Equal(t, []string{"go", "web"}, data.Tags)
+		assert.Equal(t, map[string]string{"team": "CORE"}, data.Labels)
+		require.NotNil(t, data.Address)
+		assert.Equal(t, "New York", data.Address.City)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(SignupBody{}, gv.JSON)(handlerFunc))
+
+	// Create a request with padded, mixed case and decomposed values
+	body := `{
+		"email": " Foo@Example.COM ",
+		"username": "Cafe\u0301   Owner",
+		"tags": [" Go", "WEB "],
+		"labels": {"team": "core"},
+		"address": {"city": " New\tYork "}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateModifiersBeforeDefaults(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "name", gv.Validated[*SearchQuery](r, gv.Query).Sort)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(SearchQuery{}, gv.Query)(handlerFunc))
+
+	// Create a request with a blank sort
+	req := httptest.NewRequest(http.MethodGet, "/test?sort=%20%20", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that the blank value is trimmed and then replaced by the default
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateModifiersInvalidTag(t *testing.T) {
+	type Invalid struct {
+		Name string `json:"name" mod:"trim,reverse"`
+	}
+
+	// Verify that an unknown modifier is reported when the middleware is created
+	assert.PanicsWithValue(t, "invalid mod of field Name: unknown modifier reverse", func() {
+		gv.Validate(Invalid{}, gv.JSON)
+	})
+}
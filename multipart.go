@@ -0,0 +1,140 @@
+package gv
+
+import (
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/go-playground/validator/v10"
+)
+
+// DefaultMultipartMemory is the default number of bytes of a multipart body
+// that are kept in memory, the rest of the file parts is stored on disk
+const DefaultMultipartMemory = 32 << 20
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// isMultipart reports whether the request has a multipart/form-data body
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// bindFiles sets the *multipart.FileHeader and []*multipart.FileHeader fields
+// of dst to the file parts named by their schema tags
+func bindFiles(dst any, files map[string][]*multipart.FileHeader) {
+	v := reflect.ValueOf(dst).Elem()
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		if f.Type != fileHeaderType && f.Type != fileHeadersType {
+			continue
+		}
+		name := tagName(f, "schema")
+		fhs := files[name]
+		if len(fhs) == 0 {
+			continue
+		}
+		if f.Type == fileHeaderType {
+			v.FieldByIndex(f.Index).Set(reflect.ValueOf(fhs[0]))
+		} else {
+			v.FieldByIndex(f.Index).Set(reflect.ValueOf(fhs))
+		}
+	}
+}
+
+// registerFileValidations registers the rules for uploaded files on v:
+//
+//   - filesize=5MB limits the size of the file, units are B, KB, MB and GB
+//   - filetype=image/png image/jpeg limits the type of the file, detected
+//     from its content. Wildcards like image/* are allowed
+//
+// The count of files is limited with the built-in min and max rules. The
+// rules panic on fields that are not files, like other misused rules do
+func registerFileValidations(v *validator.Validate) {
+	_ = v.RegisterValidation("filesize", validateFileSize)
+	_ = v.RegisterValidation("filetype", validateFileType)
+}
+
+// fileHeader returns the file header a file rule is applied to. It panics
+// when the field is not a file
+func fileHeader(fl validator.FieldLevel) *multipart.FileHeader {
+	field := fl.Field()
+	if field.Type() == fileHeaderType {
+		fh, _ := field.Interface().(*multipart.FileHeader)
+		return fh
+	}
+	if field.Type() == fileHeaderType.Elem() && field.CanAddr() {
+		fh, _ := field.Addr().Interface().(*multipart.FileHeader)
+		return fh
+	}
+	panic("invalid " + fl.GetTag() + " rule of field " + fl.StructFieldName() +
+		": " + field.Type().String() + " is not a file")
+}
+
+func validateFileSize(fl validator.FieldLevel) bool {
+	fh := fileHeader(fl)
+	if fh == nil {
+		return false
+	}
+	limit, ok := parseSize(fl.Param())
+	return ok && fh.Size <= limit
+}
+
+func validateFileType(fl validator.FieldLevel) bool {
+	fh := fileHeader(fl)
+	if fh == nil {
+		return false
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	detected, err := mimetype.DetectReader(f)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range strings.Fields(fl.Param()) {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(detected.String(), prefix+"/") {
+				return true
+			}
+		} else if detected.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeUnits holds the multipliers of the units accepted by parseSize
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses sizes like "512", "100KB" or "5MB"
+func parseSize(s string) (int64, bool) {
+	multiplier := int64(1)
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(strings.ToUpper(s), u.suffix); ok {
+			s, multiplier = n, u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
@@ -0,0 +1,228 @@
+package gv_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UploadSchema defines a schema for a multipart form with files
+type UploadSchema struct {
+	Title       string                  `schema:"title" validate:"required"`
+	Avatar      *multipart.FileHeader   `schema:"avatar" validate:"required,filesize=1KB,filetype=image/png image/jpeg"`
+	Attachments []*multipart.FileHeader `schema:"attachments" validate:"max=2,dive,filetype=text/*"`
+}
+
+// pngHeader is enough of a PNG file for its type to be detected
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field, filename string
+	content         []byte
+}
+
+// newUploadRequest creates a multipart/form-data request with a title and the given files
+func newUploadRequest(t *testing.T, title string, parts ...uploadPart) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("title", title))
+	for _, p := range parts {
+		fw, err := w.CreateFormFile(p.field, p.filename)
+		require.NoError(t, err)
+		_, err = fw.Write(p.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/test", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestValidateMultipartOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*UploadSchema](r, gv.Form)
+		assert.Equal(t, "Holidays", data.Title)
+		require.NotNil(t, data.Avatar)
+		assert.Equal(t, "me.png", data.Avatar.Filename)
+		require.Len(t, data.Attachments, 2)
+		assert.Equal(t, "b.txt", data.Attachments[1].Filename)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with an instance that keeps at most 1KB in memory
+	m := gv.New(gv.WithMultipartMemory(1 << 10))
+	router.Handle("/test", m.Validate(UploadSchema{}, gv.Form)(handlerFunc)).Methods(http.MethodPost)
+
+	// Create a multipart request with an image and two text files
+	req := newUploadRequest(t, "Holidays",
+		uploadPart{"avatar", "me.png", pngHeader},
+		uploadPart{"attachments", "a.txt", []byte("first")},
+		uploadPart{"attachments", "b.txt", []byte("second")},
+	)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateMultipartFileRules(t *testing.T) {
+	// Create a request with an avatar that is not an image and too many attachments
+	req := newUploadRequest(t, "Holidays",
+		uploadPart{"avatar", "me.png", []byte("not an image")},
+		uploadPart{"attachments", "a.txt", []byte("first")},
+		uploadPart{"attachments", "b.txt", []byte("second")},
+		uploadPart{"attachments", "c.txt", []byte("third")},
+	)
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UploadSchema{}, gv.Form)
+	})
+
+	// Verify that the type and the count of files are reported
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, "avatar", err.Fields[0].Field)
+	assert.Equal(t, "filetype", err.Fields[0].Rule)
+	assert.Equal(t, "attachments", err.Fields[1].Field)
+	assert.Equal(t, "max", err.Fields[1].Rule)
+
+	// Create a request with an image that is too large
+	req = newUploadRequest(t, "Holidays",
+		uploadPart{"avatar", "me.png", append(pngHeader, make([]byte, 2<<10)...)},
+	)
+
+	err = captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UploadSchema{}, gv.Form)
+	})
+
+	// Verify that the size is reported
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "avatar", err.Fields[0].Field)
+	assert.Equal(t, "filesize", err.Fields[0].Rule)
+	assert.Equal(t, "1KB", err.Fields[0].Param)
+}
+
+func TestValidateMultipartMissingFile(t *testing.T) {
+	// Create a request without the required avatar
+	req := newUploadRequest(t, "Holidays")
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UploadSchema{}, gv.Form)
+	})
+
+	// Verify that the missing file is reported
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "avatar", err.Fields[0].Field)
+	assert.Equal(t, "required", err.Fields[0].Rule)
+}
+
+func TestValidateCustomMaxSizeRuleKept(t *testing.T) {
+	// Create a validator with a maxsize rule for strings, which the file
+	// rules must not replace
+	v := validator.New()
+	v.RegisterValidation("maxsize", func(fl validator.FieldLevel) bool {
+		return len(fl.Field().String()) <= 3
+	})
+	m := gv.New(gv.WithValidator(v))
+
+	type NameSchema struct {
+		Name string `schema:"name" validate:"maxsize=3"`
+	}
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/test", m.Validate(NameSchema{}, gv.Query)(handlerFunc))
+
+	// Create a request with a short name
+	req := httptest.NewRequest(http.MethodGet, "/test?name=ab", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that the custom rule was applied
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateFileRuleOnNonFilePanics(t *testing.T) {
+	type NameSchema struct {
+		Name string `schema:"name" validate:"filesize=1KB"`
+	}
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/test", gv.New().Validate(NameSchema{}, gv.Query)(handlerFunc))
+
+	// Verify that the misused rule is reported
+	req := httptest.NewRequest(http.MethodGet, "/test?name=ab", nil)
+	assert.PanicsWithValue(t, "invalid filesize rule of field Name: string is not a file", func() {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func TestValidateMultipartRemovesFiles(t *testing.T) {
+	// Store the file parts that do not fit in memory in a directory of the test
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	m := gv.New(gv.WithMultipartMemory(1))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.NotEmpty(t, files)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/test", m.Validate(UploadSchema{}, gv.Form)(handlerFunc))
+
+	// Create a request with an image larger than the memory limit
+	req := newUploadRequest(t, "Holidays", uploadPart{"avatar", "me.png", pngHeader})
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that the file parts are removed once the request is handled
+	assert.Equal(t, http.StatusOK, rr.Code)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
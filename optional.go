@@ -0,0 +1,155 @@
+package gv
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Optional is a field of a schema that tells apart a value that was not sent,
+// one sent as null and one sent with a value, zero values included. In JSON
+// bodies null sets Null; other sources set the value from its text.
+//
+// Validation rules of the field apply to Value when it is set and not null;
+// otherwise the field is empty, so required fails and omitempty skips the
+// other rules. Optional types are registered on the validators of the
+// Middleware when the schema is passed to Validate or when a validator is set.
+// Optional types of other values than scalars, time.Time and time.Duration are
+// written to the validator then, so pass their schemas to Validate before the
+// Middleware serves requests
+type Optional[T any] struct {
+	Value T
+	// Set tells that the field was sent, as null or with a value
+	Set bool
+	// Null tells that the field was sent as null
+	Null bool
+}
+
+// Get returns the value and whether it was sent and is not null
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for fields
+// that are present in the document
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.Value, o.Set, o.Null = zero, true, string(data) == "null"
+	if o.Null {
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// MarshalJSON implements json.Marshaler. Fields that are not set or null are
+// written as null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so that Optional is
+// decoded from the values of the other sources and from default tags
+func (o *Optional[T]) UnmarshalText(text []byte) error {
+	var zero T
+	o.Value, o.Set, o.Null = zero, true, false
+	return setDefault(reflect.ValueOf(&o.Value).Elem(), string(text))
+}
+
+// validationValue returns the value the validation rules apply to
+func (o Optional[T]) validationValue() any {
+	if !o.Set || o.Null {
+		return nil
+	}
+	return o.Value
+}
+
+// optional is implemented by every Optional type
+type optional interface {
+	validationValue() any
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// builtinOptionals are the Optional types registered on every validator of a
+// Middleware when it is set, so that schemas using only them do not write to a
+// validator that serves requests
+var builtinOptionals = []reflect.Type{
+	reflect.TypeOf(Optional[string]{}),
+	reflect.TypeOf(Optional[bool]{}),
+	reflect.TypeOf(Optional[int]{}),
+	reflect.TypeOf(Optional[int8]{}),
+	reflect.TypeOf(Optional[int16]{}),
+	reflect.TypeOf(Optional[int32]{}),
+	reflect.TypeOf(Optional[int64]{}),
+	reflect.TypeOf(Optional[uint]{}),
+	reflect.TypeOf(Optional[uint8]{}),
+	reflect.TypeOf(Optional[uint16]{}),
+	reflect.TypeOf(Optional[uint32]{}),
+	reflect.TypeOf(Optional[uint64]{}),
+	reflect.TypeOf(Optional[float32]{}),
+	reflect.TypeOf(Optional[float64]{}),
+	reflect.TypeOf(Optional[time.Time]{}),
+	reflect.TypeOf(Optional[time.Duration]{}),
+}
+
+// optionalTypes lists the Optional types of the fields of typ
+func optionalTypes(typ reflect.Type) []reflect.Type {
+	var types []reflect.Type
+	walkFields(typ, func(f reflect.StructField) {
+		t := f.Type
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Implements(optionalType) {
+			types = append(types, t)
+		}
+	})
+	return types
+}
+
+// registerOptionalTypes makes the validator v apply the rules of Optional
+// fields of the given types to their values
+func registerOptionalTypes(v *validator.Validate, types []reflect.Type) {
+	for _, t := range types {
+		v.RegisterCustomTypeFunc(func(v reflect.Value) any {
+			return v.Interface().(optional).validationValue()
+		}, reflect.New(t).Elem().Interface())
+	}
+}
+
+// registerOptionals registers the Optional types of the fields of typ that
+// are new to the Middleware on its validators. Validators set later get them
+// when they are set
+func (m *Middleware) registerOptionals(typ reflect.Type) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var types []reflect.Type
+	for _, t := range optionalTypes(typ) {
+		if !m.optionals[t] {
+			m.optionals[t] = true
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return
+	}
+	registerOptionalTypes(m.validator, types)
+	for _, v := range m.scenarios {
+		registerOptionalTypes(v, types)
+	}
+}
+
+// knownOptionals returns the Optional types registered on the validators of
+// the Middleware. It is called with m.mu held
+func (m *Middleware) knownOptionals() []reflect.Type {
+	types := make([]reflect.Type, 0, len(m.optionals))
+	for t := range m.optionals {
+		types = append(types, t)
+	}
+	return types
+}
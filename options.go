@@ -0,0 +1,62 @@
+package gv
+
+import "github.com/go-playground/validator/v10"
+
+// ValidateOption configures a single Validate call
+type ValidateOption func(*validateConfig)
+
+// validateConfig collects the options of a Validate call. Unset options fall
+// back to the settings of the Middleware
+type validateConfig struct {
+	maxBodySize *int64
+	strict      *bool
+	// partial validates only the fields present in a JSON body
+	partial bool
+	// load returns the resource a patch is applied to
+	load LoaderFunc
+	// scenario selects the validate.<name> tags applied in place of the
+	// validate tags, scoped lists the fields that have them and
+	// scenarioValidator applies them
+	scenario          string
+	scoped            map[string]bool
+	scenarioValidator *validator.Validate
+	// key is an additional context key the validated data is stored under
+	key any
+}
+
+// LimitBody sets the number of bytes of the request body that the Validate
+// call reads, overriding the limit of the Middleware. 0 means no limit
+func LimitBody(n int64) ValidateOption {
+	return func(c *validateConfig) {
+		c.maxBodySize = &n
+	}
+}
+
+// Strict makes the Validate call reject unknown fields and data after the
+// document of JSON bodies, overriding the setting of the Middleware. For the
+// Params, Query and Form sources it rejects unknown keys, or ignores them when
+// false, whatever the schema decoder is configured to do with them. Keys are
+// matched against the schema tags of the fields, so it does not support
+// decoders with another alias tag
+func Strict(strict bool) ValidateOption {
+	return func(c *validateConfig) {
+		c.strict = &strict
+	}
+}
+
+// bodyLimit returns the body size limit of the call
+func (c *validateConfig) bodyLimit(m *Middleware) int64 {
+	if c.maxBodySize != nil {
+		return *c.maxBodySize
+	}
+	return m.maxBodySize
+}
+
+// decodeOptions returns the settings of the call passed to the decoders
+func (c *validateConfig) decodeOptions(m *Middleware) decodeOptions {
+	o := decodeOptions{strictJSON: m.strictJSON, strictValues: c.strict, load: c.load}
+	if c.strict != nil {
+		o.strictJSON = *c.strict
+	}
+	return o
+}
@@ -0,0 +1,87 @@
+package gv_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBody(t *testing.T) {
+	body := `{"id":123,"profile":{"name":"John","email":"john@example.com"}}`
+
+	form := url.Values{}
+	form.Add("id", "123")
+	form.Add("profile.name", strings.Repeat("John", 100))
+	form.Add("profile.email", "john@example.com")
+
+	for _, tc := range []struct {
+		name string
+		src  gv.Source
+		req  func() *http.Request
+	}{
+		{"JSON", gv.JSON, func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+		}},
+		{"Form", gv.Form, func() *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return req
+		}},
+		{"Multipart", gv.Form, func() *http.Request {
+			return newUploadRequest(t, "Holidays", uploadPart{"avatar", "me.png", bytes.Repeat([]byte("a"), 100)})
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := captureError(t, "/test", tc.req(), func(m *gv.Middleware) mux.MiddlewareFunc {
+				return m.Validate(TestSchema{}, tc.src, gv.LimitBody(32))
+			})
+
+			// Verify that the body was rejected because of its size
+			assert.Equal(t, gv.KindTooLarge, err.Kind)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, err.Status())
+			require.Len(t, err.Fields, 1)
+			assert.Equal(t, "maxbytes", err.Fields[0].Rule)
+			assert.Equal(t, "32", err.Fields[0].Param)
+		})
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	// Create an instance with a default limit
+	m := gv.New(gv.WithMaxBodySize(16))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called when the body is within the limit
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register a route with the default limit and a route that overrides it
+	router.Handle("/default", m.Validate(TestSchema{}, gv.JSON)(handlerFunc))
+	router.Handle("/override", m.Validate(TestSchema{}, gv.JSON, gv.LimitBody(1<<10))(handlerFunc))
+
+	body := `{"id":123,"profile":{"name":"John","email":"john@example.com"}}`
+	for path, code := range map[string]int{
+		"/default":  http.StatusRequestEntityTooLarge,
+		"/override": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		// Let the router handle the request
+		router.ServeHTTP(rr, req)
+
+		// Verify the response
+		assert.Equal(t, code, rr.Code, path)
+	}
+}
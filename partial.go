@@ -0,0 +1,12 @@
+package gv
+
+// Partial makes the Validate call apply the validation rules only to the
+// fields present in a JSON body, so that one schema serves both creating a
+// resource and updating some of its fields. Presence is read from the keys
+// of the JSON document, not from zero values. Other sources are validated as
+// a whole
+func Partial() ValidateOption {
+	return func(c *validateConfig) {
+		c.partial = true
+	}
+}
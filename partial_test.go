@@ -0,0 +1,104 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+)
+
+// ResourceAddress defines the nested address of Resource
+type ResourceAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"required,numeric"`
+}
+
+// Resource defines a schema shared by the create and update routes
+type Resource struct {
+	Name    string          `json:"name" validate:"required,min=2"`
+	Email   string          `json:"email" validate:"required,email"`
+	Address ResourceAddress `json:"address"`
+	Tags    []ResourceTag   `json:"tags" validate:"dive"`
+}
+
+// ResourceTag defines the elements of the tags of Resource
+type ResourceTag struct {
+	Name  string `json:"name" validate:"required"`
+	Color string `json:"color" validate:"required,hexcolor"`
+}
+
+func TestValidatePartialOK(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+	}{
+		{"Empty", `{}`},
+		{"Field", `{"email":"john@example.com"}`},
+		{"Nested", `{"address":{"city":"Paris"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with partial validation
+			router.Handle("/test", gv.Validate(Resource{}, gv.JSON, gv.Partial())(handlerFunc)).Methods(http.MethodPatch)
+
+			// Create a request updating some of the fields
+			req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestValidatePartialPresentFields(t *testing.T) {
+	// Create a request with an explicitly empty name, an invalid nested zip and tags
+	// with an invalid color and without a color
+	body := `{"name":"","address":{"zip":"abc"},"tags":[{"color":"red"},{"name":"blue"}]}`
+	req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(Resource{}, gv.JSON, gv.Partial())
+	})
+
+	// Verify that only the present fields are validated
+	fields := make(map[string]string)
+	for _, f := range err.Fields {
+		fields[f.Field] = f.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"name":          "required",
+		"address.zip":   "numeric",
+		"tags[0].color": "hexcolor",
+	}, fields)
+}
+
+func TestValidateWithoutPartial(t *testing.T) {
+	// Create a request updating a single field
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"email":"john@example.com"}`))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(Resource{}, gv.JSON)
+	})
+
+	// Verify that the absent required fields are reported
+	fields := make([]string, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"name", "address.city", "address.zip"}, fields)
+}
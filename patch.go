@@ -0,0 +1,522 @@
+package gv
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatch applies a JSON Merge Patch (RFC 7396) body to the resource
+	// returned by the Loader of the Validate call and validates the result.
+	// Errors are reported with the paths of the resource, which are the ones
+	// of the merge patch
+	MergePatch Source = "MergePatch"
+	// JSONPatch applies a JSON Patch (RFC 6902) body to the resource returned
+	// by the Loader of the Validate call and validates the result. Errors of
+	// fields set by an operation point to it, like "[2].value.city"
+	JSONPatch Source = "JSONPatch"
+)
+
+// LoaderFunc returns the current resource a patch is applied to. It is
+// encoded to JSON, unless it is a []byte or a json.RawMessage holding JSON.
+// Errors are passed to the error handler in an *Error of kind KindLoad, or
+// KindNotFound when they wrap ErrNotFound; an *Error is passed as is
+type LoaderFunc func(r *http.Request) (any, error)
+
+// ErrNotFound is returned or wrapped by a LoaderFunc when the resource does
+// not exist, so that the request is rejected with HTTP 404 (Not Found)
+var ErrNotFound = errors.New("gv: resource not found")
+
+// Loader sets the function that returns the resource the MergePatch and
+// JSONPatch sources apply the patch to. Without it the patch is applied to
+// an empty JSON object
+func Loader(load LoaderFunc) ValidateOption {
+	return func(c *validateConfig) {
+		c.load = load
+	}
+}
+
+// PatchOperation is an operation of a JSON Patch document
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// patchKey is the context key of the raw patch validated for a source
+type patchKey Source
+
+// RawPatch returns the body of the MergePatch or JSONPatch request validated
+// for the source, or nil
+func RawPatch(r *http.Request, src Source) json.RawMessage {
+	raw, _ := r.Context().Value(patchKey(src)).(json.RawMessage)
+	return raw
+}
+
+// PatchOperations returns the operations of the JSONPatch request validated
+// for the source, or nil
+func PatchOperations(r *http.Request, src Source) []PatchOperation {
+	var ops []PatchOperation
+	if err := json.Unmarshal(RawPatch(r, src), &ops); err != nil {
+		return nil
+	}
+	return ops
+}
+
+// loadDocument returns the resource the patch is applied to, decoded into
+// generic JSON values. Its errors are not errors of the request, they are
+// returned as an *Error that keeps them from clients
+func loadDocument(r *http.Request, src Source, o decodeOptions) (any, error) {
+	if o.load == nil {
+		return map[string]any{}, nil
+	}
+	resource, err := o.load(r)
+	if err != nil {
+		return nil, newLoadError(src, err)
+	}
+	var data []byte
+	switch v := resource.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		if data, err = json.Marshal(v); err != nil {
+			return nil, newLoadError(src, err)
+		}
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, newLoadError(src, err)
+	}
+	return doc, nil
+}
+
+// newLoadError wraps an error of the loader into an *Error without field
+// errors. Errors that are already an *Error are returned as is
+func newLoadError(src Source, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	kind := KindLoad
+	if errors.Is(err, ErrNotFound) {
+		kind = KindNotFound
+	}
+	return &Error{Kind: kind, Source: src, Err: err}
+}
+
+// decodeDocument decodes the patched resource into dst
+func decodeDocument(doc any, dst any, o decodeOptions) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if o.strictJSON {
+		return unmarshalStrictJSON(data, dst)
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func decodeMergePatch(r *http.Request, dst any, o decodeOptions) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var patch any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return err
+	}
+	doc, err := loadDocument(r, MergePatch, o)
+	if err != nil {
+		return err
+	}
+	return decodeDocument(mergePatch(doc, patch), dst, o)
+}
+
+// mergePatch applies a JSON Merge Patch to doc as described by RFC 7396
+func mergePatch(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(target, k)
+		} else {
+			target[k] = mergePatch(target[k], v)
+		}
+	}
+	return target
+}
+
+func decodeJSONPatch(r *http.Request, dst any, o decodeOptions) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var ops []PatchOperation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return err
+	}
+	doc, err := loadDocument(r, JSONPatch, o)
+	if err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if doc, err = applyPatchOperation(doc, op); err != nil {
+			var opErr *patchOperationError
+			if errors.As(err, &opErr) {
+				return FieldErrors{{Field: "[" + strconv.Itoa(i) + "]." + opErr.member, Rule: "patch", Value: opErr.value, Message: opErr.msg}}
+			}
+			return err
+		}
+	}
+
+	if err := decodeDocument(doc, dst, o); err != nil {
+		// Fields set by an operation are reported with it
+		fields := fieldErrors("", err)
+		for i, f := range fields {
+			if f.Field != "" {
+				fields[i].Field = patchFieldPath(ops, f.Field)
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+// jsonPatchFieldPath reports the fields of a JSONPatch schema with the
+// operation that set them
+func jsonPatchFieldPath(_ *http.Request, raw []byte, typ reflect.Type, ns string) (Source, string) {
+	path := wirePath(typ, ns, "json")
+	var ops []PatchOperation
+	if err := json.Unmarshal(raw, &ops); err != nil {
+		return JSONPatch, path
+	}
+	return JSONPatch, patchFieldPath(ops, path)
+}
+
+// patchFieldPath returns the path of the last operation that set the field at
+// path, like "[2].value.city" for a field inside the value of the operation or
+// "[3].path" for a removed field. The path is returned as is when no
+// operation set the field
+func patchFieldPath(ops []PatchOperation, path string) string {
+	field := pathTokens(path)
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		target, err := parsePointer(op.Path)
+		if err != nil || len(target) > len(field) || !tokensEqual(target, field[:len(target)]) {
+			continue
+		}
+		index := "[" + strconv.Itoa(i) + "]"
+		rest := formatTokens(field[len(target):])
+		switch op.Op {
+		case "add", "replace":
+			return index + ".value" + rest
+		case "move", "copy":
+			return index + ".from" + rest
+		case "remove":
+			return index + ".path"
+		}
+	}
+	return path
+}
+
+// pathTokens splits a field path like "items[0].name" into its keys
+func pathTokens(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
+
+// formatTokens joins keys into the continuation of a field path, indexing
+// numeric keys like "[0].name"
+func formatTokens(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if _, err := strconv.Atoi(t); err == nil {
+			b.WriteString("[" + t + "]")
+		} else {
+			b.WriteString("." + t)
+		}
+	}
+	return b.String()
+}
+
+func tokensEqual(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// patchOperationError tells why an operation could not be applied and which
+// member of the operation is at fault
+type patchOperationError struct {
+	member string
+	value  any
+	msg    string
+}
+
+func (e *patchOperationError) Error() string {
+	return e.member + ": " + e.msg
+}
+
+// applyPatchOperation applies an operation of a JSON Patch to doc as
+// described by RFC 6902 and returns the new document
+func applyPatchOperation(doc any, op PatchOperation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, &patchOperationError{"path", op.Path, err.Error()}
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, &patchOperationError{"value", nil, "is required"}
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, &patchOperationError{"value", nil, "must be valid JSON"}
+		}
+		switch op.Op {
+		case "add":
+			doc, err = pointerAdd(doc, path, value)
+		case "replace":
+			doc, err = pointerReplace(doc, path, value)
+		default:
+			var current any
+			if current, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(current, value) {
+				return nil, &patchOperationError{"value", value, "does not match the value at " + op.Path}
+			}
+		}
+	case "remove":
+		doc, _, err = pointerRemove(doc, path)
+	case "move", "copy":
+		from, ferr := parsePointer(op.From)
+		if ferr != nil {
+			return nil, &patchOperationError{"from", op.From, ferr.Error()}
+		}
+		var value any
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, &patchOperationError{"from", op.From, "must not be a parent of path"}
+			}
+			if doc, value, err = pointerRemove(doc, from); err != nil {
+				return nil, &patchOperationError{"from", op.From, err.Error()}
+			}
+		} else {
+			if value, err = pointerGet(doc, from); err != nil {
+				return nil, &patchOperationError{"from", op.From, err.Error()}
+			}
+			value = copyJSON(value)
+		}
+		doc, err = pointerAdd(doc, path, value)
+	default:
+		return nil, &patchOperationError{"op", op.Op, "must be one of: add remove replace move copy test"}
+	}
+	if err != nil {
+		var opErr *patchOperationError
+		if errors.As(err, &opErr) {
+			return nil, err
+		}
+		return nil, &patchOperationError{"path", op.Path, err.Error()}
+	}
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, errors.New("must be a JSON pointer")
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// pointerGet returns the value at path
+func pointerGet(doc any, path []string) (any, error) {
+	for _, t := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[t]
+			if !ok {
+				return nil, errors.New("does not exist")
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(t, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, errors.New("does not exist")
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd adds the value at path, inserting it into arrays
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	return pointerUpdate(doc, path, func(parent any, key string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[key] = value
+			return node, nil
+		case []any:
+			i := len(node)
+			if key != "-" {
+				var err error
+				if i, err = arrayIndex(key, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, errors.New("does not exist")
+		}
+	}, value)
+}
+
+// pointerReplace replaces the existing value at path
+func pointerReplace(doc any, path []string, value any) (any, error) {
+	return pointerUpdate(doc, path, func(parent any, key string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[key]; !ok {
+				return nil, errors.New("does not exist")
+			}
+			node[key] = value
+			return node, nil
+		case []any:
+			i, err := arrayIndex(key, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = value
+			return node, nil
+		default:
+			return nil, errors.New("does not exist")
+		}
+	}, value)
+}
+
+// pointerRemove removes the value at path and returns it
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("must not be the whole document")
+	}
+	var removed any
+	doc, err := pointerUpdate(doc, path, func(parent any, key string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			v, ok := node[key]
+			if !ok {
+				return nil, errors.New("does not exist")
+			}
+			removed = v
+			delete(node, key)
+			return node, nil
+		case []any:
+			i, err := arrayIndex(key, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, errors.New("does not exist")
+		}
+	}, nil)
+	return doc, removed, err
+}
+
+// pointerUpdate calls fn with the container of the last token of path and
+// stores the container it returns. An empty path replaces the document with
+// root
+func pointerUpdate(doc any, path []string, fn func(parent any, key string) (any, error), root any) (any, error) {
+	if len(path) == 0 {
+		return root, nil
+	}
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, errors.New("does not exist")
+		}
+		child, err := pointerUpdate(child, path[1:], fn, root)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = child
+		return node, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerUpdate(node[i], path[1:], fn, root)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+		return node, nil
+	default:
+		return nil, errors.New("does not exist")
+	}
+}
+
+// arrayIndex parses an array index of a JSON pointer that must not be
+// greater than last
+func arrayIndex(token string, last int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > last || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New("has an invalid array index " + token)
+	}
+	return i, nil
+}
+
+// copyJSON returns a deep copy of a generic JSON value
+func copyJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, item := range v {
+			c[k] = copyJSON(item)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, item := range v {
+			c[i] = copyJSON(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
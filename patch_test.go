@@ -0,0 +1,231 @@
+package gv_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PatchAddress defines the nested address of PatchUser
+type PatchAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+// PatchUser defines the schema of a resource updated with patches
+type PatchUser struct {
+	Name    string       `json:"name" validate:"required,min=2"`
+	Email   string       `json:"email" validate:"required,email"`
+	Address PatchAddress `json:"address"`
+	Tags    []string     `json:"tags" validate:"max=3"`
+}
+
+// loadUser returns the stored user the patches are applied to
+func loadUser(r *http.Request) (any, error) {
+	return PatchUser{
+		Name:    "John",
+		Email:   "john@example.com",
+		Address: PatchAddress{City: "Paris"},
+		Tags:    []string{"admin"},
+	}, nil
+}
+
+func TestValidateMergePatchOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*PatchUser](r, gv.Body)
+		assert.Equal(t, "Johnny", data.Name)
+		assert.Equal(t, "john@example.com", data.Email)
+		assert.Equal(t, "Lyon", data.Address.City)
+		assert.Empty(t, data.Tags)
+		assert.JSONEq(t, `{"name":"Johnny","address":{"city":"Lyon"},"tags":null}`, string(gv.RawPatch(r, gv.Body)))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the loader of the stored user
+	router.Handle("/users/{id}", gv.Validate(PatchUser{}, gv.Body, gv.Loader(loadUser))(handlerFunc))
+
+	// Create a merge patch request
+	body := `{"name":"Johnny","address":{"city":"Lyon"},"tags":null}`
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateMergePatchErrors(t *testing.T) {
+	// Create a merge patch removing the email
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"email":null}`))
+
+	err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(PatchUser{}, gv.MergePatch, gv.Loader(loadUser))
+	})
+
+	// Verify that the error points to the merge path
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, gv.MergePatch, err.Fields[0].Source)
+	assert.Equal(t, "email", err.Fields[0].Field)
+	assert.Equal(t, "required", err.Fields[0].Rule)
+}
+
+func TestValidateJSONPatchOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*PatchUser](r, gv.Body)
+		assert.Equal(t, "Johnny", data.Name)
+		assert.Equal(t, []string{"go", "admin", "web"}, data.Tags)
+		assert.Equal(t, "Paris", data.Address.City)
+
+		ops := gv.PatchOperations(r, gv.Body)
+		require.Len(t, ops, 4)
+		assert.Equal(t, "test", ops[0].Op)
+		assert.Equal(t, "/tags/-", ops[3].Path)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the loader of the stored user
+	router.Handle("/users/{id}", gv.Validate(PatchUser{}, gv.Body, gv.Loader(loadUser))(handlerFunc))
+
+	// Create a JSON patch request
+	body := `[
+		{"op":"test","path":"/name","value":"John"},
+		{"op":"replace","path":"/name","value":"Johnny"},
+		{"op":"add","path":"/tags/0","value":"go"},
+		{"op":"add","path":"/tags/-","value":"web"}
+	]`
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateJSONPatchErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		body  string
+		kind  gv.ErrorKind
+		field string
+		rule  string
+	}{
+		{"Value", `[{"op":"replace","path":"/address","value":{"city":""}}]`, gv.KindValidation, "[0].value.city", "required"},
+		{"Null", `[{"op":"add","path":"/tags/-","value":"go"},{"op":"replace","path":"/email","value":null}]`, gv.KindValidation, "[1].value", "required"},
+		{"Remove", `[{"op":"remove","path":"/name"}]`, gv.KindValidation, "[0].path", "required"},
+		{"Copy", `[{"op":"copy","from":"/email","path":"/name"},{"op":"replace","path":"/email","value":"x"}]`, gv.KindValidation, "[1].value", "email"},
+		{"Type", `[{"op":"replace","path":"/name","value":42}]`, gv.KindDecode, "[0].value", "type"},
+		{"MissingPath", `[{"op":"replace","path":"/nickname","value":"J"}]`, gv.KindDecode, "[0].path", "patch"},
+		{"Test", `[{"op":"test","path":"/name","value":"Jane"}]`, gv.KindDecode, "[0].value", "patch"},
+		{"Op", `[{"op":"rename","path":"/name"}]`, gv.KindDecode, "[0].op", "patch"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(tc.body))
+
+			err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+				return m.Validate(PatchUser{}, gv.JSONPatch, gv.Loader(loadUser))
+			})
+
+			// Verify that the error points to the operation
+			assert.Equal(t, tc.kind, err.Kind)
+			require.Len(t, err.Fields, 1)
+			assert.Equal(t, gv.JSONPatch, err.Fields[0].Source)
+			assert.Equal(t, tc.field, err.Fields[0].Field)
+			assert.Equal(t, tc.rule, err.Fields[0].Rule)
+		})
+	}
+}
+
+func TestValidatePatchLoaderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		err    error
+		kind   gv.ErrorKind
+		status int
+	}{
+		{"NotFound", fmt.Errorf("user 1: %w", gv.ErrNotFound), gv.KindNotFound, http.StatusNotFound},
+		{"Failure", errors.New("dial tcp 10.0.0.7:5432: connection refused"), gv.KindLoad, http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create an instance whose loader fails
+			var got *gv.Error
+			m := gv.New(gv.WithErrorHandler(func(err error) http.HandlerFunc {
+				require.True(t, errors.As(err, &got))
+				return gv.ProblemErrorHandler(err)
+			}))
+			load := func(r *http.Request) (any, error) { return nil, tc.err }
+
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that should never be called because loading will fail
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Fail(t, "should not reach here")
+			})
+
+			// Register the route with the failing loader
+			router.Handle("/users/{id}", m.Validate(PatchUser{}, gv.MergePatch, gv.Loader(load))(handlerFunc))
+
+			// Create a merge patch request
+			req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name":"Johnny"}`))
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify that the error of the loader reaches the handler but not the client
+			assert.Equal(t, tc.kind, got.Kind)
+			assert.ErrorIs(t, got, tc.err)
+			assert.Empty(t, got.Fields)
+			assert.Equal(t, tc.status, rr.Code)
+			assert.NotContains(t, rr.Body.String(), tc.err.Error())
+
+			// Verify that the default error handler does not show it either
+			router = mux.NewRouter()
+			router.Handle("/users/{id}", gv.New().Validate(PatchUser{}, gv.MergePatch, gv.Loader(load))(handlerFunc))
+			req = httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name":"Johnny"}`))
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tc.status, rr.Code)
+			assert.NotContains(t, rr.Body.String(), tc.err.Error())
+		})
+	}
+}
+
+func TestValidatePatchLoaderError(t *testing.T) {
+	// Create a loader that rejects the request with its own error
+	load := func(r *http.Request) (any, error) {
+		return nil, &gv.Error{Kind: gv.KindValidation, Fields: gv.FieldErrors{{Field: "id", Rule: "owner", Message: "is not yours"}}}
+	}
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name":"Johnny"}`))
+
+	err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(PatchUser{}, gv.MergePatch, gv.Loader(load))
+	})
+
+	// Verify that the error is passed as is
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "owner", err.Fields[0].Rule)
+}
@@ -0,0 +1,115 @@
+package gv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// wirePath converts a validator struct namespace like "Schema.Items[3].Price"
+// into the path the client sent, like "items[3].price", using the tag of the
+// source. Segments that cannot be resolved are kept as is
+func wirePath(typ reflect.Type, ns string, tag string) string {
+	var b strings.Builder
+	t := typ
+	for i, seg := range splitNamespace(ns) {
+		if i == 0 {
+			// The first segment is the name of the top-level struct
+			continue
+		}
+		if strings.HasPrefix(seg, "[") {
+			b.WriteString(seg)
+			t = elemType(t)
+			continue
+		}
+		name := seg
+		t = indirectType(t)
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(seg); ok {
+				t = f.Type
+				name = tagName(f, tag)
+				if name == "" {
+					// Embedded structs without a name are promoted by the decoders
+					continue
+				}
+			} else {
+				t = nil
+			}
+		} else {
+			t = nil
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// tagName returns the wire name of a struct field for the given tag. It is
+// empty for embedded structs without an explicit name
+func tagName(f reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if tag == "xml" {
+		name = strings.ReplaceAll(name, ">", ".")
+	}
+	switch {
+	case name == "-":
+		return f.Name
+	case name != "":
+		return name
+	case f.Anonymous && indirectType(f.Type).Kind() == reflect.Struct:
+		return ""
+	default:
+		return f.Name
+	}
+}
+
+// splitNamespace splits a validator namespace into field names and
+// bracketed indexes or keys. Map keys may contain dots, so brackets are
+// matched before splitting on dots
+func splitNamespace(ns string) []string {
+	var segs []string
+	for ns != "" {
+		switch {
+		case ns[0] == '.':
+			ns = ns[1:]
+		case ns[0] == '[':
+			end := strings.IndexByte(ns, ']')
+			if end < 0 {
+				return append(segs, ns)
+			}
+			segs = append(segs, ns[:end+1])
+			ns = ns[end+1:]
+		default:
+			end := strings.IndexAny(ns, ".[")
+			if end < 0 {
+				return append(segs, ns)
+			}
+			segs = append(segs, ns[:end])
+			ns = ns[end:]
+		}
+	}
+	return segs
+}
+
+// indirectType dereferences pointer types
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// elemType returns the element type of slices, arrays and maps, or nil
+func elemType(t reflect.Type) reflect.Type {
+	t = indirectType(t)
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
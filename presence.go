@@ -0,0 +1,154 @@
+package gv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// presenceKey is the context key of the presence of the fields of the JSON
+// body validated for a source
+type presenceKey Source
+
+// presentField is a field present in a JSON document
+type presentField struct {
+	// ns is the validator namespace of the field relative to the schema,
+	// like "Address.City" or "Items[0].Name"
+	ns string
+	// path is the path of the field named by the json tags, like
+	// "address.city" or "items[0].name"
+	path string
+	// null tells that the field was sent as null
+	null bool
+}
+
+// presence holds the fields present in a JSON body. They are only resolved
+// when they are first needed
+type presence struct {
+	once   sync.Once
+	raw    []byte
+	typ    reflect.Type
+	fields []presentField
+	paths  map[string]bool
+}
+
+// newPresence creates the presence of the fields of typ in the JSON document raw
+func newPresence(raw []byte, typ reflect.Type) *presence {
+	return &presence{raw: raw, typ: typ}
+}
+
+// resolve walks the JSON document once
+func (p *presence) resolve() {
+	p.once.Do(func() {
+		p.paths = make(map[string]bool)
+		var doc any
+		if err := json.NewDecoder(bytes.NewReader(p.raw)).Decode(&doc); err != nil {
+			return
+		}
+		walkPresentJSON(doc, p.typ, "", "", &p.fields)
+		for _, f := range p.fields {
+			p.paths[f.path] = f.null
+		}
+	})
+}
+
+// namespaces returns the validator namespaces of the present fields
+func (p *presence) namespaces() []string {
+	p.resolve()
+	ns := make([]string, len(p.fields))
+	for i, f := range p.fields {
+		ns[i] = f.ns
+	}
+	return ns
+}
+
+// lookup reports whether the field at path is present and whether it is null
+func (p *presence) lookup(path string) (present, null bool) {
+	p.resolve()
+	null, present = p.paths[path]
+	return present, null
+}
+
+func walkPresentJSON(v any, t reflect.Type, ns, path string, fields *[]presentField) {
+	t = indirectType(t)
+	if t == nil || t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			for k, item := range v {
+				f, ok := jsonField(t, k)
+				if !ok {
+					continue
+				}
+				field := presentField{
+					ns:   joinPath(ns, fieldNamespace(t, f.Index)),
+					path: joinPath(path, tagName(f, "json")),
+					null: item == nil,
+				}
+				*fields = append(*fields, field)
+				walkPresentJSON(item, f.Type, field.ns, field.path, fields)
+			}
+		case reflect.Map:
+			for k, item := range v {
+				field := presentField{ns: ns + "[" + k + "]", path: path + "[" + k + "]", null: item == nil}
+				*fields = append(*fields, field)
+				walkPresentJSON(item, t.Elem(), field.ns, field.path, fields)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				index := "[" + strconv.Itoa(i) + "]"
+				field := presentField{ns: ns + index, path: path + index, null: item == nil}
+				*fields = append(*fields, field)
+				walkPresentJSON(item, t.Elem(), field.ns, field.path, fields)
+			}
+		}
+	}
+}
+
+// fieldNamespace returns the validator namespace of the field of the struct t
+// at index, naming the embedded structs a promoted field is reached through
+func fieldNamespace(t reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, idx := range index {
+		f := t.Field(idx)
+		names[i] = f.Name
+		t = indirectType(f.Type)
+	}
+	return strings.Join(names, ".")
+}
+
+// Present reports whether the field at path, like "address.city" or
+// "items[0].name", was sent in the JSON body validated for the source,
+// explicit nulls included. Paths are named by the json tags like the field
+// paths of errors. It is false for other sources
+func Present(r *http.Request, src Source, path string) bool {
+	p, ok := r.Context().Value(presenceKey(src)).(*presence)
+	if !ok {
+		return false
+	}
+	present, _ := p.lookup(path)
+	return present
+}
+
+// Null reports whether the field at path was sent as an explicit null in the
+// JSON body validated for the source
+func Null(r *http.Request, src Source, path string) bool {
+	p, ok := r.Context().Value(presenceKey(src)).(*presence)
+	if !ok {
+		return false
+	}
+	_, null := p.lookup(path)
+	return null
+}
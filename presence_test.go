@@ -0,0 +1,301 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AuditAddress defines the nested address of AuditBody
+type AuditAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+// AuditBody defines a schema whose present fields are tracked
+type AuditBody struct {
+	Nickname *string        `json:"nickname"`
+	Bio      string         `json:"bio"`
+	Address  *AuditAddress  `json:"address"`
+	Items    []AuditAddress `json:"items"`
+}
+
+// OptionalBody defines a schema with tri-state fields
+type OptionalBody struct {
+	Nickname gv.Optional[string] `json:"nickname" validate:"omitempty,min=3"`
+	Bio      gv.Optional[string] `json:"bio"`
+	Age      gv.Optional[int]    `json:"age" validate:"required,gte=18"`
+}
+
+// OptionalQuery defines a schema with a tri-state query parameter
+type OptionalQuery struct {
+	Page gv.Optional[int] `schema:"page" validate:"omitempty,gte=1"`
+}
+
+func TestPresent(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for path, want := range map[string][2]bool{
+			"nickname":      {true, true},
+			"bio":           {false, false},
+			"address":       {true, false},
+			"address.city":  {true, false},
+			"address.zip":   {false, false},
+			"items[0]":      {true, false},
+			"items[0].zip":  {true, true},
+			"items[1].city": {false, false},
+		} {
+			assert.Equal(t, want[0], gv.Present(r, gv.Body, path), path)
+			assert.Equal(t, want[1], gv.Null(r, gv.Body, path), path)
+		}
+
+		// Only the source the body was validated for is tracked
+		assert.False(t, gv.Present(r, gv.JSON, "nickname"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(AuditBody{}, gv.Body)(handlerFunc))
+
+	// Create a request with an explicit null, a nested object and an array
+	body := `{"nickname":null,"address":{"city":"Paris"},"items":[{"zip":null}]}`
+	req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestOptionalOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*OptionalBody](r, gv.JSON)
+
+		// The nickname was sent as null
+		assert.True(t, data.Nickname.Set)
+		assert.True(t, data.Nickname.Null)
+
+		// The bio was not sent
+		assert.False(t, data.Bio.Set)
+		_, ok := data.Bio.Get()
+		assert.False(t, ok)
+
+		// The age was sent with a value
+		age, ok := data.Age.Get()
+		assert.True(t, ok)
+		assert.Equal(t, 21, age)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(OptionalBody{}, gv.JSON)(handlerFunc))
+
+	// Create a request with a null nickname and without a bio
+	req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(`{"nickname":null,"age":21}`))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestOptionalRules(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		body  string
+		field string
+		rule  string
+	}{
+		{"Value", `{"nickname":"ab","age":21}`, "nickname", "min"},
+		{"Absent", `{"nickname":"abc"}`, "age", "required"},
+		{"Null", `{"age":null}`, "age", "required"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(tc.body))
+
+			err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+				return m.Validate(OptionalBody{}, gv.JSON)
+			})
+
+			// Verify that the rules apply to the value of the field
+			require.Len(t, err.Fields, 1)
+			assert.Equal(t, tc.field, err.Fields[0].Field)
+			assert.Equal(t, tc.rule, err.Fields[0].Rule)
+		})
+	}
+}
+
+func TestOptionalQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  gv.Optional[int]
+	}{
+		{"Absent", "", gv.Optional[int]{}},
+		{"Value", "page=2", gv.Optional[int]{Value: 2, Set: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.want, gv.Validated[*OptionalQuery](r, gv.Query).Page)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with the validated handler
+			router.Handle("/test", gv.Validate(OptionalQuery{}, gv.Query)(handlerFunc))
+
+			// Create a request with the query of the case
+			req := httptest.NewRequest(http.MethodGet, "/test?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+// OptionalFilter defines a schema with Optional types no other schema uses
+type OptionalFilter struct {
+	Score  gv.Optional[float64] `json:"score" validate:"omitempty,gte=0"`
+	Active gv.Optional[bool]    `json:"active"`
+}
+
+func TestOptionalConcurrentRoutes(t *testing.T) {
+	m := gv.New()
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/test", m.Validate(OptionalBody{}, gv.JSON)(handlerFunc))
+
+	// Serve requests while other routes register their Optional types on
+	// the same validator
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPatch, "/test", strings.NewReader(`{"age":21}`))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}()
+		go func() {
+			defer wg.Done()
+			m.Validate(OptionalFilter{}, gv.JSON)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOptionalValidatorSetLater(t *testing.T) {
+	defer gv.Validator(validator.New())
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because validation will fail
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route, then set the validator of the default instance
+	router.Handle("/test", gv.Validate(OptionalFilter{}, gv.JSON)(handlerFunc))
+	gv.Validator(validator.New())
+
+	// Create a request with a negative score
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"score":-1}`))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that the rules apply to the value with the new validator
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "'gte' tag")
+}
+
+// SlowBody defines a schema whose rule waits for the test
+type SlowBody struct {
+	Name string `json:"name" validate:"wait"`
+}
+
+func TestOptionalRoutesDuringSlowValidation(t *testing.T) {
+	// Create an instance with a rule that blocks until released
+	entered, release := make(chan struct{}), make(chan struct{})
+	v := validator.New()
+	v.RegisterValidation("wait", func(fl validator.FieldLevel) bool {
+		close(entered)
+		<-release
+		return true
+	})
+	m := gv.New(gv.WithValidator(v))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the slow rule
+	router.Handle("/slow", m.Validate(SlowBody{}, gv.JSON)(handlerFunc))
+
+	// Start a request that waits in the rule
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/slow", strings.NewReader(`{"name":"John"}`))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-entered
+
+	// Verify that routes with Optional fields can be created meanwhile
+	created := make(chan struct{})
+	go func() {
+		defer close(created)
+		m.Validate(OptionalBody{}, gv.JSON)
+		m.Validate(OptionalQuery{}, gv.Query)
+	}()
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		assert.Fail(t, "creating routes waited for the validation in flight")
+	}
+	close(release)
+	<-done
+}
@@ -0,0 +1,63 @@
+package gv
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object extended with the list of
+// rejected fields
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Errors   FieldErrors `json:"errors,omitempty"`
+}
+
+// problemDetails holds the detail message of every error kind
+var problemDetails = map[ErrorKind]string{
+	KindDecode:     "The request could not be decoded.",
+	KindValidation: "The request failed validation.",
+	KindMediaType:  "The Content-Type of the request body is not supported.",
+	KindTooLarge:   "The request body is too large.",
+	KindNotFound:   "The resource was not found.",
+	KindLoad:       "The resource could not be loaded.",
+}
+
+// ProblemErrorHandler is an ErrorHandlerFunc that responds with
+// application/problem+json. The instance member is set to the path template of
+// the matched mux route and the errors member lists the rejected fields.
+// Select it with WithErrorHandler or ErrorHandler
+func ProblemErrorHandler(err error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var e *Error
+		if !errors.As(err, &e) {
+			e = newError(KindDecode, "", err)
+		}
+
+		status := e.Status()
+		problem := Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: problemDetails[e.Kind],
+			Errors: e.Fields,
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			problem.Instance, _ = route.GetPathTemplate()
+		}
+
+		w.Header().Set("Content-Type", ProblemContentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(problem)
+	}
+}
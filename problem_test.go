@@ -0,0 +1,119 @@
+package gv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemErrorHandlerValidation(t *testing.T) {
+	// Create an instance that responds with problem details
+	m := gv.New(gv.WithErrorHandler(gv.ProblemErrorHandler))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because validation will fail
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/users/{id}", m.Validate(TestSchema{}, gv.JSON)(handlerFunc)).Methods(http.MethodPost)
+
+	// Create a JSON body with an invalid email
+	body := `{"id":1,"profile":{"name":"John","email":"secret"}}`
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the problem details
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, gv.ProblemContentType, rr.Header().Get("Content-Type"))
+	assert.NotContains(t, rr.Body.String(), "TestSchema")
+	assert.NotContains(t, rr.Body.String(), "secret")
+
+	var problem gv.Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "The request failed validation.", problem.Detail)
+	assert.Equal(t, "/users/{id}", problem.Instance)
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, gv.JSON, problem.Errors[0].Source)
+	assert.Equal(t, "email", problem.Errors[0].Rule)
+}
+
+func TestProblemErrorHandlerDecode(t *testing.T) {
+	// Create an instance that responds with problem details
+	m := gv.New(gv.WithErrorHandler(gv.ProblemErrorHandler))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because decoding will fail
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test/{id}", m.Validate(ParamsTestSchema{}, gv.Params)(handlerFunc))
+
+	// Create a request with a non-integer ID
+	req := httptest.NewRequest(http.MethodGet, "/test/abc", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the problem details
+	var problem gv.Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, "The request could not be decoded.", problem.Detail)
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "id", problem.Errors[0].Field)
+	assert.Equal(t, "type", problem.Errors[0].Rule)
+}
+
+func TestProblemErrorHandlerMediaType(t *testing.T) {
+	// Create an instance that responds with problem details
+	m := gv.New(gv.WithErrorHandler(gv.ProblemErrorHandler))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because the body is not supported
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", m.Validate(TestSchema{}, gv.Body)(handlerFunc))
+
+	// Create a request with a plain text body
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the problem details
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	var problem gv.Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, "Unsupported Media Type", problem.Title)
+	assert.Equal(t, http.StatusUnsupportedMediaType, problem.Status)
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "text/plain", problem.Errors[0].Param)
+}
@@ -0,0 +1,185 @@
+package gv
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Request binds one schema from several places of the request. Each field
+// declares where it is read from with the in tag:
+//
+//   - in:"path" reads a URL parameter named by the schema tag
+//   - in:"query" reads a query parameter named by the schema tag
+//   - in:"header" reads a header named by the header tag
+//   - in:"cookie" reads a cookie named by the cookie tag
+//   - in:"body" decodes the body into the field like the Body source does
+//
+// The whole schema is validated at once, so cross-field rules like gtfield
+// work across places. Errors are reported with the source of each field
+const Request Source = "Request"
+
+// inSources maps the values of the in tag to the sources that read them
+var inSources = map[string]Source{
+	"path":   Params,
+	"query":  Query,
+	"header": Header,
+	"cookie": Cookie,
+	"body":   Body,
+}
+
+// inFields groups the top-level fields of the struct t by their in tag. It
+// panics on unknown values of the tag
+func inFields(t reflect.Type) map[Source][]reflect.StructField {
+	fields := make(map[Source][]reflect.StructField)
+	for _, f := range reflect.VisibleFields(t) {
+		in := f.Tag.Get("in")
+		if in == "" || !f.IsExported() {
+			continue
+		}
+		src, ok := inSources[in]
+		if !ok {
+			panic("unknown in tag of field " + f.Name + ": " + in)
+		}
+		fields[src] = append(fields[src], f)
+	}
+	return fields
+}
+
+func (m *Middleware) decodeRequest(r *http.Request, dst any, o decodeOptions) error {
+	v := reflect.ValueOf(dst).Elem()
+	fields := inFields(v.Type())
+
+	var errs []*Error
+	collect := func(src Source, err error) {
+		if err != nil {
+			errs = append(errs, newDecodeError(src, err))
+		}
+	}
+
+	if fs := fields[Params]; len(fs) > 0 {
+		vars := make(map[string][]string)
+		for k, val := range mux.Vars(r) {
+			vars[k] = []string{val}
+		}
+		collect(Params, m.decodePlace(dst, Params, fs, vars, o))
+	}
+	if fs := fields[Query]; len(fs) > 0 {
+		collect(Query, m.decodePlace(dst, Query, fs, r.URL.Query(), o))
+	}
+	if fs := fields[Header]; len(fs) > 0 {
+		collect(Header, m.decodeValues(m.headerDecoder, "header", dst, Header, namedValues(fs, "header", r.Header.Values), o))
+	}
+	if fs := fields[Cookie]; len(fs) > 0 {
+		cookies, err := parseCookies(r.Header)
+		if err != nil {
+			collect(Cookie, malformedCookieError(err))
+		} else {
+			lookup := func(name string) []string { return cookies[name] }
+			collect(Cookie, m.decodeValues(m.cookieDecoder, "cookie", dst, Cookie, namedValues(fs, "cookie", lookup), o))
+		}
+	}
+	if fs := fields[Body]; len(fs) > 0 && hasBody(r) {
+		src, ok := m.bodySource(r)
+		if !ok {
+			errs = append(errs, newMediaTypeError(r))
+		} else {
+			for _, f := range fs {
+				collect(src, m.sources[src].decode(r, v.FieldByIndex(f.Index).Addr().Interface(), o))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return joinErrors(errs)
+	}
+	return nil
+}
+
+// hasBody reports whether the request has a body. The body itself may be
+// wrapped, so it is told by the length and the transfer encoding
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && (r.ContentLength != 0 || len(r.TransferEncoding) > 0)
+}
+
+// namedValues collects the values of the fields named by tag
+func namedValues(fields []reflect.StructField, tag string, lookup func(name string) []string) map[string][]string {
+	values := make(map[string][]string)
+	for _, f := range fields {
+		name := tagName(f, tag)
+		if v := lookup(name); len(v) > 0 {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// decodePlace decodes the values of the Params or Query place into the fields
+// read from it. Values are only decoded into fields of their place, so that a
+// query key cannot set a path field. In the strict mode the keys of no field
+// of the place are rejected
+func (m *Middleware) decodePlace(dst any, src Source, fields []reflect.StructField, values map[string][]string, o decodeOptions) error {
+	picked, others := fieldValues(fields, values)
+	if o.strictValues != nil && *o.strictValues {
+		unknown := append(others, unknownKeys(reflect.TypeOf(dst), "schema", picked)...)
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return unknownKeysError(src, unknown, values)
+		}
+	}
+	return m.decodeValues(m.schemaDecoder(), "schema", dst, src, picked, o)
+}
+
+// fieldValues picks the values whose key starts with the schema tag of one of
+// the fields and returns the other keys
+func fieldValues(fields []reflect.StructField, values map[string][]string) (map[string][]string, []string) {
+	picked := make(map[string][]string)
+	var others []string
+values:
+	for k, v := range values {
+		first, _, _ := strings.Cut(k, ".")
+		for _, f := range fields {
+			if strings.EqualFold(tagName(f, "schema"), first) {
+				picked[k] = v
+				continue values
+			}
+		}
+		others = append(others, k)
+	}
+	return picked, others
+}
+
+// requestFieldPath returns the source and the wire path of a field of a
+// Request schema. Fields of the body are reported without the name of the
+// body field, with the tag of the source that decoded the body
+func (m *Middleware) requestFieldPath(r *http.Request, _ []byte, typ reflect.Type, ns string) (Source, string) {
+	segs := splitNamespace(ns)
+	t := indirectType(typ)
+	if len(segs) < 2 || t.Kind() != reflect.Struct {
+		return Request, wirePath(typ, ns, "")
+	}
+	f, ok := t.FieldByName(segs[1])
+	if !ok {
+		return Request, wirePath(typ, ns, "")
+	}
+
+	switch src := inSources[f.Tag.Get("in")]; src {
+	case Params, Query:
+		return src, wirePath(typ, ns, "schema")
+	case Header, Cookie:
+		return src, wirePath(typ, ns, m.sources[src].tag)
+	case Body:
+		from, ok := m.bodySource(r)
+		if !ok {
+			return Body, wirePath(typ, ns, "")
+		}
+		// Replace the root and the body field by a root of the body type
+		rest := ns[len(segs[0])+1+len(segs[1]):]
+		return from, wirePath(f.Type, f.Name+rest, m.sources[from].tag)
+	default:
+		return Request, wirePath(typ, ns, "")
+	}
+}
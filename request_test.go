@@ -0,0 +1,207 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UpdateUserRequest defines a schema read from several places of the request
+type UpdateUserRequest struct {
+	ID       int            `in:"path" schema:"id" validate:"required,min=1"`
+	Limit    int            `in:"query" schema:"limit" validate:"ltefield=MaxLimit"`
+	DryRun   bool           `in:"query" schema:"dry_run"`
+	MaxLimit int            `in:"header" header:"X-Max-Limit" validate:"required"`
+	Session  string         `in:"cookie" cookie:"session" validate:"required"`
+	User     UpdateUserBody `in:"body" validate:"required"`
+}
+
+// UpdateUserBody defines the body of UpdateUserRequest
+type UpdateUserBody struct {
+	Name  string `json:"name" xml:"name" validate:"required"`
+	Email string `json:"email_address" xml:"email" validate:"required,email"`
+}
+
+// newUpdateUserRequest creates a request for UpdateUserRequest with the given query and JSON body
+func newUpdateUserRequest(query, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/users/42?"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Max-Limit", "100")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	return req
+}
+
+func TestValidateRequestOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*UpdateUserRequest](r, gv.Request)
+		assert.Equal(t, 42, data.ID)
+		assert.Equal(t, 10, data.Limit)
+		assert.True(t, data.DryRun)
+		assert.Equal(t, 100, data.MaxLimit)
+		assert.Equal(t, "abc", data.Session)
+		assert.Equal(t, "John", data.User.Name)
+		assert.Equal(t, "john@example.com", data.User.Email)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/users/{id}", gv.Validate(UpdateUserRequest{}, gv.Request)(handlerFunc))
+
+	// Create a request with values in the path, query, header, cookie and body
+	req := newUpdateUserRequest("limit=10&dry_run=true", `{"name":"John","email_address":"john@example.com"}`)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateRequestFieldErrors(t *testing.T) {
+	// Create a request whose limit exceeds the header and whose body has an invalid email
+	req := newUpdateUserRequest("limit=500", `{"name":"John","email_address":"invalid"}`)
+
+	err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UpdateUserRequest{}, gv.Request)
+	})
+
+	// Verify that every field is reported with its own source and wire path
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, gv.Query, err.Fields[0].Source)
+	assert.Equal(t, "limit", err.Fields[0].Field)
+	assert.Equal(t, "ltefield", err.Fields[0].Rule)
+	assert.Equal(t, gv.JSON, err.Fields[1].Source)
+	assert.Equal(t, "email_address", err.Fields[1].Field)
+	assert.Equal(t, "email", err.Fields[1].Rule)
+}
+
+func TestValidateRequestDecodeErrors(t *testing.T) {
+	// Create a request with an invalid query value and a malformed body
+	req := newUpdateUserRequest("limit=many", `{"name":`)
+
+	err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UpdateUserRequest{}, gv.Request)
+	})
+
+	// Verify that the errors of both places are reported
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, gv.Query, err.Fields[0].Source)
+	assert.Equal(t, "limit", err.Fields[0].Field)
+	assert.Equal(t, gv.JSON, err.Fields[1].Source)
+}
+
+func TestValidateRequestStrict(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*UpdateUserRequest](r, gv.Request)
+		assert.Equal(t, 42, data.ID)
+		assert.Equal(t, 100, data.MaxLimit)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register a lenient and a strict route
+	router.Handle("/users/{id}", gv.Validate(UpdateUserRequest{}, gv.Request, gv.Strict(false))(handlerFunc))
+	router.Handle("/strict/users/{id}", gv.Validate(UpdateUserRequest{}, gv.Request, gv.Strict(true))(handlerFunc))
+
+	body := `{"name":"John","email_address":"john@example.com"}`
+	for query, code := range map[string]int{
+		"limit=10": http.StatusOK,
+		// A query key cannot set a field read from the path
+		"limit=10&id=99": http.StatusOK,
+	} {
+		req := newUpdateUserRequest(query, body)
+		rr := httptest.NewRecorder()
+
+		// Let the router handle the request
+		router.ServeHTTP(rr, req)
+
+		// Verify the response
+		assert.Equal(t, code, rr.Code, query)
+	}
+
+	// Create a strict request with valid values in every place
+	req := newUpdateUserRequest("limit=10", body)
+	req.URL.Path = "/strict" + req.URL.Path
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestValidateRequestStrictUnknownKeys(t *testing.T) {
+	// Create a request whose query sets the path field and an unknown key
+	req := newUpdateUserRequest("limit=10&id=99&sort=name", `{"name":"John","email_address":"john@example.com"}`)
+
+	err := captureError(t, "/users/{id}", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(UpdateUserRequest{}, gv.Request, gv.Strict(true))
+	})
+
+	// Verify that the query keys of no query field are rejected
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, gv.FieldError{Source: gv.Query, Field: "id", Rule: "unknown", Value: "99", Message: "is not allowed"}, err.Fields[0])
+	assert.Equal(t, "sort", err.Fields[1].Field)
+}
+
+// ShowUserRequest defines a schema whose body is optional
+type ShowUserRequest struct {
+	ID     int             `in:"path" schema:"id" validate:"required"`
+	Fields *UpdateUserBody `in:"body"`
+}
+
+func TestValidateRequestWithoutBody(t *testing.T) {
+	// Create an instance with a body limit
+	m := gv.New(gv.WithMaxBodySize(1 << 10))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*ShowUserRequest](r, gv.Request)
+		assert.Equal(t, 1, data.ID)
+		assert.Nil(t, data.Fields)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/users/{id}", m.Validate(ShowUserRequest{}, gv.Request)(handlerFunc))
+
+	// Create a request without a body
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify that the missing body is not rejected as an unsupported media type
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestValidateRequestUnknownIn(t *testing.T) {
+	type BadRequest struct {
+		ID int `in:"paht" schema:"id"`
+	}
+
+	// Verify that the typo is reported when the route is created
+	assert.PanicsWithValue(t, "unknown in tag of field ID: paht", func() {
+		gv.Validate(BadRequest{}, gv.Request)
+	})
+}
@@ -0,0 +1,69 @@
+package gv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// RetrievalError tells why validated data could not be retrieved from a request
+type RetrievalError struct {
+	// Source is the source the data was requested for
+	Source Source
+	// Expected is the type the data was requested as
+	Expected reflect.Type
+	// Stored is the type of the stored data, nil when no data is stored, which
+	// usually means the middleware was not applied to the route
+	Stored reflect.Type
+}
+
+func (e *RetrievalError) Error() string {
+	if e.Stored == nil {
+		return fmt.Sprintf("gv: no data of source %s is stored in the request, is gv.Validate(..., gv.%s) applied to the route?", e.Source, e.Source)
+	}
+	return fmt.Sprintf("gv: data of source %s is %s, not %s", e.Source, e.Stored, e.Expected)
+}
+
+// GetValidated returns the validated data of the source from the request
+// context. When several schemas were validated for the source, the one of
+// type T is returned. It returns a *RetrievalError naming the expected type,
+// the stored type and the source when there is no data of type T
+func GetValidated[T any](r *http.Request, src Source) (T, error) {
+	stored := storedValue[T](r.Context(), src)
+	if data, ok := stored.(T); ok {
+		return data, nil
+	}
+	var zero T
+	return zero, &RetrievalError{
+		Source:   src,
+		Expected: reflect.TypeOf((*T)(nil)).Elem(),
+		Stored:   reflect.TypeOf(stored),
+	}
+}
+
+// storedValue returns the data of the source stored in ctx, preferring the
+// data of the schema of type T
+func storedValue[T any](ctx context.Context, src Source) any {
+	if stored := ctx.Value(schemaKey{src, reflect.TypeOf((*T)(nil)).Elem()}); stored != nil {
+		return stored
+	}
+	return ctx.Value(sourceKey(src))
+}
+
+// TryValidated returns the validated data of the source from the request
+// context and whether there is data of type T
+func TryValidated[T any](r *http.Request, src Source) (T, bool) {
+	data, err := GetValidated[T](r, src)
+	return data, err == nil
+}
+
+// MustValidated returns the validated data of the source from the request
+// context. It panics with a *RetrievalError when there is no data of type T
+func MustValidated[T any](r *http.Request, src Source) T {
+	data, err := GetValidated[T](r, src)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,65 @@
+package gv_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrieveValidated(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that retrieves the data in every way
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The right type is found
+		data, ok := gv.TryValidated[*ParamsTestSchema](r, gv.Params)
+		require.True(t, ok)
+		assert.Equal(t, 123, data.ID)
+
+		// A wrong type is reported with both types and the source
+		_, err := gv.GetValidated[ParamsTestSchema](r, gv.Params)
+		var retrievalErr *gv.RetrievalError
+		require.True(t, errors.As(err, &retrievalErr))
+		assert.Equal(t, gv.Params, retrievalErr.Source)
+		assert.Equal(t, reflect.TypeOf(ParamsTestSchema{}), retrievalErr.Expected)
+		assert.Equal(t, reflect.TypeOf(&ParamsTestSchema{}), retrievalErr.Stored)
+		assert.EqualError(t, err, "gv: data of source Params is *gv_test.ParamsTestSchema, not gv_test.ParamsTestSchema")
+
+		// A source that was not validated is reported as missing
+		_, ok = gv.TryValidated[*ParamsTestSchema](r, gv.Query)
+		assert.False(t, ok)
+		_, err = gv.GetValidated[*ParamsTestSchema](r, gv.Query)
+		require.True(t, errors.As(err, &retrievalErr))
+		assert.Nil(t, retrievalErr.Stored)
+
+		// MustValidated and Validated panic with the descriptive error
+		assert.PanicsWithError(t, err.Error(), func() {
+			gv.MustValidated[*ParamsTestSchema](r, gv.Query)
+		})
+		assert.PanicsWithError(t, err.Error(), func() {
+			gv.Validated[*ParamsTestSchema](r, gv.Query)
+		})
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test/{id}", gv.Validate(ParamsTestSchema{}, gv.Params)(handlerFunc))
+
+	// Create a request with a valid ID
+	req := httptest.NewRequest(http.MethodGet, "/test/123", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
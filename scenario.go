@@ -0,0 +1,302 @@
+package gv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Scenario makes the Validate call apply the rules of the validate.<name>
+// tags, like validate.update, in place of the validate tags of the same
+// fields. Fields without a tag for the scenario keep their validate tag, so
+// one schema serves several operations:
+//
+//	type User struct {
+//		Name  string `json:"name" validate:"required" validate.update:"omitempty,min=2"`
+//		Email string `json:"email" validate:"required,email"`
+//	}
+//
+// A tag for the scenario on a struct field replaces the rules of the whole
+// field, nested fields included. Custom rules used by the tags are applied by
+// the validator set with WithScenarioValidator or ScenarioValidator
+func Scenario(name string) ValidateOption {
+	return func(c *validateConfig) {
+		c.scenario = name
+	}
+}
+
+// scenarioTag returns the tag holding the rules of a scenario
+func scenarioTag(name string) string {
+	return "validate." + name
+}
+
+// WithScenarioValidator sets the validator that applies the validate.<name>
+// tags of the scenario, so that it can be configured like the validator of
+// the Middleware, with the same custom rules, aliases, struct level
+// validations and tag name functions. Its tag name is set to validate.<name>
+// and the file rules filesize and filetype are registered on it, so it must
+// not be shared with other scenarios or the Middleware
+func WithScenarioValidator(name string, v *validator.Validate) Option {
+	return func(m *Middleware) {
+		m.setScenarioValidator(name, v)
+	}
+}
+
+// ScenarioValidator sets the validator that applies the validate.<name> tags
+// of the scenario on the default instance, see WithScenarioValidator. It is
+// used by the routes created with the scenario after it is set
+func ScenarioValidator(name string, v *validator.Validate) {
+	std.setScenarioValidator(name, v)
+}
+
+func (m *Middleware) setScenarioValidator(name string, v *validator.Validate) {
+	v.SetTagName(scenarioTag(name))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registerFileValidations(v)
+	registerOptionalTypes(v, m.knownOptionals())
+	m.scenarios[name] = v
+}
+
+// scenarioValidator returns the validator of the scenario. Scenarios without
+// a validator set get one that only knows the built-in and the file rules
+func (m *Middleware) scenarioValidator(name string) *validator.Validate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.scenarios[name]; ok {
+		return v
+	}
+	v := validator.New()
+	v.SetTagName(scenarioTag(name))
+	registerFileValidations(v)
+	registerOptionalTypes(v, m.knownOptionals())
+	m.scenarios[name] = v
+	return v
+}
+
+// scopedFields lists the validator namespaces, without indexes, of the fields
+// of typ that have the given tag
+func scopedFields(typ reflect.Type, tag string) map[string]bool {
+	scoped := make(map[string]bool)
+	prefix := ""
+	if t := indirectType(typ); t.Name() != "" {
+		prefix = t.Name() + "."
+	}
+	walkScopedFields(typ, tag, prefix, scoped, make(map[reflect.Type]bool))
+	return scoped
+}
+
+func walkScopedFields(typ reflect.Type, tag, ns string, scoped map[string]bool, parents map[reflect.Type]bool) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || parents[typ] {
+		return
+	}
+	parents[typ] = true
+	defer delete(parents, typ)
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if _, ok := f.Tag.Lookup(tag); ok {
+			scoped[ns+f.Name] = true
+			continue
+		}
+		walkScopedFields(f.Type, tag, ns+f.Name+".", scoped, parents)
+	}
+}
+
+// stripIndexes removes the slice and map indexes of a validator namespace
+func stripIndexes(ns string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(ns, '[')
+		if i < 0 {
+			b.WriteString(ns)
+			return b.String()
+		}
+		b.WriteString(ns[:i])
+		j := strings.IndexByte(ns[i:], ']')
+		if j < 0 {
+			return b.String()
+		}
+		ns = ns[i+j+1:]
+	}
+}
+
+// validateScenario validates the schema with the rules of the scenario of the
+// call: the validator of the Middleware skips the fields that have a tag for
+// the scenario and the validator of the scenario applies them. When present
+// is not nil, only the fields present in the body are validated
+func (m *Middleware) validateScenario(ctx context.Context, schema any, typ reflect.Type, cfg *validateConfig, present []string) error {
+	prefix := ""
+	if typ.Name() != "" {
+		prefix = typ.Name() + "."
+	}
+	var include map[string]bool
+	if present != nil {
+		include = make(map[string]bool, len(present))
+		for _, ns := range present {
+			include[prefix+ns] = true
+		}
+	}
+
+	var errs validator.ValidationErrors
+	collect := func(err error) error {
+		var validationErrs validator.ValidationErrors
+		if err != nil && !errors.As(err, &validationErrs) {
+			return err
+		}
+		errs = append(errs, validationErrs...)
+		return nil
+	}
+
+	err := m.validator.StructFilteredCtx(ctx, schema, func(ns []byte) bool {
+		return (include != nil && !include[string(ns)]) || cfg.scoped[stripIndexes(string(ns))]
+	})
+	if err := collect(err); err != nil {
+		return err
+	}
+
+	v := cfg.scenarioValidator
+	if include != nil {
+		err = v.StructPartialCtx(ctx, schema, present...)
+	} else {
+		err = v.StructCtx(ctx, schema)
+	}
+	if err := collect(err); err != nil {
+		return err
+	}
+
+	// The validator of the scenario does not dive into slices, arrays and
+	// maps whose field has no tag for the scenario, so their structs are
+	// validated one by one
+	w := &elementWalker{ctx: ctx, v: v, scoped: cfg.scoped, include: include, collect: collect}
+	if err := w.walk(reflect.ValueOf(schema), typ.Name(), false); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// elementWalker applies the validator of a scenario to the structs inside the
+// slices, arrays and maps of a schema that have fields with a tag for it
+type elementWalker struct {
+	ctx     context.Context
+	v       *validator.Validate
+	scoped  map[string]bool
+	include map[string]bool
+	collect func(err error) error
+}
+
+// walk visits the value at the struct namespace ns. element tells that the
+// value is an item of a slice, an array or a map
+func (w *elementWalker) walk(value reflect.Value, ns string, element bool) error {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if element && w.hasScoped(ns) {
+			if err := w.validate(value, ns); err != nil {
+				return err
+			}
+		}
+		t := value.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			fieldNs := joinPath(ns, f.Name)
+			// Fields with a tag for the scenario are validated with it
+			// as a whole
+			if !f.IsExported() || w.scoped[stripIndexes(fieldNs)] {
+				continue
+			}
+			if err := w.walk(value.Field(i), fieldNs, false); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range value.Len() {
+			if err := w.walk(value.Index(i), ns+"["+strconv.Itoa(i)+"]", true); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			if err := w.walk(iter.Value(), fmt.Sprintf("%s[%v]", ns, iter.Key().Interface()), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasScoped reports whether fields below the struct namespace ns have a tag
+// for the scenario
+func (w *elementWalker) hasScoped(ns string) bool {
+	prefix := stripIndexes(ns) + "."
+	for scoped := range w.scoped {
+		if strings.HasPrefix(scoped, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate applies the validator of the scenario to the struct at ns and
+// reports its errors with the namespaces of the schema
+func (w *elementWalker) validate(value reflect.Value, ns string) error {
+	if !value.CanAddr() {
+		// Map values are copied to be validated like the other structs
+		addr := reflect.New(value.Type())
+		addr.Elem().Set(value)
+		value = addr.Elem()
+	}
+	root := value.Type().Name()
+	rebase := func(elementNs string) string {
+		if root != "" {
+			elementNs = strings.TrimPrefix(elementNs, root+".")
+		}
+		return ns + "." + elementNs
+	}
+
+	err := w.v.StructFilteredCtx(w.ctx, value.Addr().Interface(), func(elementNs []byte) bool {
+		return w.include != nil && !w.include[rebase(string(elementNs))]
+	})
+	var validationErrs validator.ValidationErrors
+	if err == nil || !errors.As(err, &validationErrs) {
+		return err
+	}
+	rebased := make(validator.ValidationErrors, len(validationErrs))
+	for i, fe := range validationErrs {
+		rebased[i] = elementFieldError{fe, rebase(fe.Namespace()), rebase(fe.StructNamespace())}
+	}
+	return w.collect(rebased)
+}
+
+// elementFieldError is an error of the validator on a struct inside a slice,
+// an array or a map, with the namespaces of the field in the schema
+type elementFieldError struct {
+	validator.FieldError
+	ns, structNs string
+}
+
+func (e elementFieldError) Namespace() string {
+	return e.ns
+}
+
+func (e elementFieldError) StructNamespace() string {
+	return e.structNs
+}
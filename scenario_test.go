@@ -0,0 +1,256 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+)
+
+// ScenarioAddress defines the nested address of ScenarioUser
+type ScenarioAddress struct {
+	City string `json:"city" validate:"required" validate.update:"omitempty"`
+}
+
+// ScenarioUser defines a schema shared by the create, update and admin routes
+type ScenarioUser struct {
+	Name     string          `json:"name" validate:"required,min=2" validate.update:"omitempty,min=2"`
+	Email    string          `json:"email" validate:"required,email" validate.update:"omitempty,email"`
+	Role     string          `json:"role" validate:"omitempty,oneof=user" validate.admin:"required,oneof=user admin"`
+	Password string          `json:"password" validate:"required,min=8"`
+	Address  ScenarioAddress `json:"address"`
+}
+
+// scenarioFields validates the body with the options and returns the failed rules by field
+func scenarioFields(t *testing.T, body string, opts ...gv.ValidateOption) map[string]string {
+	t.Helper()
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with an instance whose error handler stores the failed rules
+	fields := make(map[string]string)
+	m := gv.New(gv.WithErrorHandler(func(err error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for _, f := range err.(*gv.Error).Fields {
+				fields[f.Field] = f.Rule
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	router.Handle("/users", m.Validate(ScenarioUser{}, gv.JSON, opts...)(handlerFunc))
+
+	// Let the router handle the request
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return fields
+}
+
+func TestValidateScenario(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		opts []gv.ValidateOption
+		want map[string]string
+	}{
+		{
+			name: "Default",
+			body: `{"role":"admin"}`,
+			want: map[string]string{"name": "required", "email": "required", "role": "oneof", "password": "required", "address.city": "required"},
+		},
+		{
+			name: "Update",
+			body: `{"password":"secret123"}`,
+			opts: []gv.ValidateOption{gv.Scenario("update")},
+			want: map[string]string{},
+		},
+		{
+			name: "UpdateRules",
+			body: `{"name":"J","email":"invalid"}`,
+			opts: []gv.ValidateOption{gv.Scenario("update")},
+			want: map[string]string{"name": "min", "email": "email", "password": "required"},
+		},
+		{
+			name: "Admin",
+			body: `{"name":"John","email":"john@example.com","password":"secret123","address":{"city":"Paris"}}`,
+			opts: []gv.ValidateOption{gv.Scenario("admin")},
+			want: map[string]string{"role": "required"},
+		},
+		{
+			name: "AdminRole",
+			body: `{"name":"John","email":"john@example.com","password":"secret123","role":"admin","address":{"city":"Paris"}}`,
+			opts: []gv.ValidateOption{gv.Scenario("admin")},
+			want: map[string]string{},
+		},
+		{
+			name: "UpdatePartial",
+			body: `{"name":"J","address":{}}`,
+			opts: []gv.ValidateOption{gv.Scenario("update"), gv.Partial()},
+			want: map[string]string{"name": "min"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, scenarioFields(t, tc.body, tc.opts...))
+		})
+	}
+}
+
+// ScenarioOrder defines a schema whose update scenario uses a custom rule
+type ScenarioOrder struct {
+	Quantity int `json:"quantity" validate:"required" validate.update:"omitempty,even"`
+}
+
+// newEvenValidator creates a validator with the custom even rule
+func newEvenValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	})
+	return v
+}
+
+func TestValidateScenarioValidator(t *testing.T) {
+	// Create an instance whose update scenario knows the custom rule
+	m := gv.New(
+		gv.WithValidator(newEvenValidator()),
+		gv.WithScenarioValidator("update", newEvenValidator()),
+	)
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the update scenario
+	router.Handle("/orders", m.Validate(ScenarioOrder{}, gv.JSON, gv.Scenario("update"))(handlerFunc))
+
+	for body, code := range map[string]int{
+		`{"quantity":4}`: http.StatusOK,
+		`{"quantity":3}`: http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPatch, "/orders", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		// Let the router handle the request
+		router.ServeHTTP(rr, req)
+
+		// Verify the response
+		assert.Equal(t, code, rr.Code, body)
+	}
+}
+
+func TestValidateScenarioConcurrentRoutes(t *testing.T) {
+	m := gv.New()
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the update scenario
+	router.Handle("/users", m.Validate(ScenarioUser{}, gv.JSON, gv.Scenario("update"))(handlerFunc))
+
+	// Serve requests while other routes create their scenarios
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPatch, "/users", strings.NewReader(`{"password":"secret123"}`))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}()
+		go func() {
+			defer wg.Done()
+			m.Validate(ScenarioUser{}, gv.JSON, gv.Scenario("admin"+strconv.Itoa(i%2)))
+		}()
+	}
+	wg.Wait()
+}
+
+// ScenarioItem defines an item of ScenarioCart whose update rules differ
+type ScenarioItem struct {
+	Name     string `json:"name" validate:"required" validate.update:"required,min=3"`
+	Quantity int    `json:"quantity" validate:"required,gte=1"`
+}
+
+// ScenarioCart defines a schema with scenario tags inside a slice and a map
+type ScenarioCart struct {
+	Items []ScenarioItem           `json:"items" validate:"required,dive"`
+	Saved map[string]*ScenarioItem `json:"saved" validate:"dive"`
+}
+
+func TestValidateScenarioElements(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		opts []gv.ValidateOption
+		want map[string]string
+	}{
+		{
+			name: "Default",
+			body: `{"items":[{"name":"ab","quantity":1},{"name":"","quantity":0}]}`,
+			want: map[string]string{"items[1].name": "required", "items[1].quantity": "required"},
+		},
+		{
+			name: "Update",
+			body: `{"items":[{"name":"ab","quantity":1},{"name":"","quantity":0}],"saved":{"later":{"name":"x","quantity":1}}}`,
+			opts: []gv.ValidateOption{gv.Scenario("update")},
+			want: map[string]string{"items[0].name": "min", "items[1].name": "required", "items[1].quantity": "required", "saved[later].name": "min"},
+		},
+		{
+			name: "UpdatePartial",
+			body: `{"items":[{"name":"ab"}]}`,
+			opts: []gv.ValidateOption{gv.Scenario("update"), gv.Partial()},
+			want: map[string]string{"items[0].name": "min"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with an instance whose error handler stores the failed rules
+			fields := make(map[string]string)
+			m := gv.New(gv.WithErrorHandler(func(err error) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					for _, f := range err.(*gv.Error).Fields {
+						fields[f.Field] = f.Rule
+					}
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			router.Handle("/carts", m.Validate(ScenarioCart{}, gv.JSON, tc.opts...)(handlerFunc))
+
+			// Let the router handle the request
+			req := httptest.NewRequest(http.MethodPut, "/carts", strings.NewReader(tc.body))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			// Verify that the rules of the scenario apply inside the slice and the map
+			assert.Equal(t, tc.want, fields)
+		})
+	}
+}
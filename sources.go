@@ -0,0 +1,300 @@
+package gv
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+// DecodeFunc decodes the data of a source into dst, a pointer to a new value
+// of the schema. Errors it returns are passed to the error handler wrapped
+// into an *Error of kind KindDecode; an *Error is passed as is
+type DecodeFunc func(r *http.Request, dst any) error
+
+// decodeOptions holds the settings of a Validate call that the built-in
+// decoders honor
+type decodeOptions struct {
+	// strictJSON rejects unknown fields and trailing data of JSON bodies
+	strictJSON bool
+	// strictValues, when set, overrides whether the schema decoder rejects
+	// unknown keys of the Params, Query and Form sources
+	strictValues *bool
+	// load returns the resource the MergePatch and JSONPatch sources apply
+	// the patch to
+	load LoaderFunc
+}
+
+// sourceDecodeFunc is a DecodeFunc that also receives the settings of the call
+type sourceDecodeFunc func(r *http.Request, dst any, o decodeOptions) error
+
+// source is a registered source
+type source struct {
+	decode sourceDecodeFunc
+	// tag is the struct tag that holds the wire names of fields
+	tag string
+	// cachesBody tells that the decoder keeps what it parses in the request,
+	// like ParseForm does, so the body does not need to be replayed
+	cachesBody bool
+	// fieldPath, when set, returns the source and the wire path of a field
+	// from its validator struct namespace, for sources that read fields from
+	// several places or that do not decode the body as it was sent. raw is
+	// what the decoder read from the body
+	fieldPath func(r *http.Request, raw []byte, typ reflect.Type, ns string) (Source, string)
+}
+
+// SourceOption configures a source registered with RegisterSource
+type SourceOption func(*sourceConfig)
+
+// sourceConfig collects the options of a source
+type sourceConfig struct {
+	tag        string
+	mediaTypes []string
+	cachesBody bool
+}
+
+// Tag sets the struct tag that holds the wire names of fields, used for the
+// field paths of errors. Field names are used when it is not set
+func Tag(name string) SourceOption {
+	return func(c *sourceConfig) {
+		c.tag = name
+	}
+}
+
+// MediaTypes makes the Body source decode bodies of the given media types with
+// the source. A type starting with "+" like "+yaml" matches the structured
+// syntax suffix of any media type
+func MediaTypes(types ...string) SourceOption {
+	return func(c *sourceConfig) {
+		c.mediaTypes = append(c.mediaTypes, types...)
+	}
+}
+
+// cachesBody marks a source whose decoder keeps what it parses in the request
+func cachesBody() SourceOption {
+	return func(c *sourceConfig) {
+		c.cachesBody = true
+	}
+}
+
+// WithSource registers a source on the Middleware, see RegisterSource
+func WithSource(name Source, decode DecodeFunc, opts ...SourceOption) Option {
+	return func(m *Middleware) {
+		m.RegisterSource(name, decode, opts...)
+	}
+}
+
+// RegisterSource registers a source on the default instance, see
+// Middleware.RegisterSource
+func RegisterSource(name Source, decode DecodeFunc, opts ...SourceOption) {
+	std.RegisterSource(name, decode, opts...)
+}
+
+// RegisterSource registers a source, or replaces a built-in one, so that it
+// can be passed to Validate. Data decoded by it is validated, reported to the
+// error handler and stored like the data of the built-in sources. Sources
+// must be registered before the Middleware serves requests
+func (m *Middleware) RegisterSource(name Source, decode DecodeFunc, opts ...SourceOption) {
+	m.register(name, func(r *http.Request, dst any, _ decodeOptions) error {
+		return decode(r, dst)
+	}, opts...)
+}
+
+// register registers a source whose decoder honors the settings of the call
+func (m *Middleware) register(name Source, decode sourceDecodeFunc, opts ...SourceOption) {
+	var c sourceConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	m.sources[name] = &source{decode: decode, tag: c.tag, cachesBody: c.cachesBody}
+	for _, t := range c.mediaTypes {
+		m.mediaTypes[strings.ToLower(t)] = name
+	}
+}
+
+// registerBuiltinSources registers the sources that come with the package
+func (m *Middleware) registerBuiltinSources() {
+	m.sources = make(map[Source]*source)
+	m.mediaTypes = make(map[string]Source)
+
+	m.register(Params, m.decodeParams, Tag("schema"))
+	m.register(Query, m.decodeQuery, Tag("schema"))
+	m.register(Form, m.decodeForm, Tag("schema"), cachesBody(),
+		MediaTypes("application/x-www-form-urlencoded", "multipart/form-data"))
+	m.register(Header, m.decodeHeader, Tag("header"))
+	m.register(Cookie, m.decodeCookie, Tag("cookie"))
+	m.register(JSON, decodeJSON, Tag("json"), MediaTypes("application/json", "+json"))
+	m.register(XML, decodeXML, Tag("xml"), MediaTypes("application/xml", "text/xml", "+xml"))
+	m.register(MergePatch, decodeMergePatch, Tag("json"), MediaTypes("application/merge-patch+json"))
+	m.register(JSONPatch, decodeJSONPatch, Tag("json"), MediaTypes("application/json-patch+json"))
+	m.sources[JSONPatch].fieldPath = jsonPatchFieldPath
+	m.register(Request, m.decodeRequest)
+	m.sources[Request].fieldPath = m.requestFieldPath
+}
+
+func (m *Middleware) decodeParams(r *http.Request, dst any, o decodeOptions) error {
+	vars := mux.Vars(r)
+	varsFixed := make(map[string][]string)
+	for k, v := range vars {
+		varsFixed[k] = []string{v}
+	}
+	return m.decodeValues(m.schemaDecoder(), "schema", dst, Params, varsFixed, o)
+}
+
+func (m *Middleware) decodeQuery(r *http.Request, dst any, o decodeOptions) error {
+	return m.decodeValues(m.schemaDecoder(), "schema", dst, Query, r.URL.Query(), o)
+}
+
+func (m *Middleware) decodeForm(r *http.Request, dst any, o decodeOptions) error {
+	if isMultipart(r) {
+		if err := r.ParseMultipartForm(m.multipartMemory); err != nil {
+			return err
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return err
+	}
+	if err := m.decodeValues(m.schemaDecoder(), "schema", dst, Form, r.PostForm, o); err != nil {
+		return err
+	}
+	if r.MultipartForm != nil {
+		bindFiles(dst, r.MultipartForm.File)
+	}
+	return nil
+}
+
+func (m *Middleware) decodeHeader(r *http.Request, dst any, o decodeOptions) error {
+	return m.decodeValues(m.headerDecoder, "header", dst, Header, tagValues(reflect.TypeOf(dst), "header", r.Header.Values), o)
+}
+
+func (m *Middleware) decodeCookie(r *http.Request, dst any, o decodeOptions) error {
+	cookies, err := parseCookies(r.Header)
+	if err != nil {
+		return malformedCookieError(err)
+	}
+	lookup := func(name string) []string { return cookies[name] }
+	return m.decodeValues(m.cookieDecoder, "cookie", dst, Cookie, tagValues(reflect.TypeOf(dst), "cookie", lookup), o)
+}
+
+func decodeJSON(r *http.Request, dst any, o decodeOptions) error {
+	if o.strictJSON {
+		return decodeStrictJSON(r, dst)
+	}
+	return json.NewDecoder(r.Body).Decode(dst)
+}
+
+func decodeXML(r *http.Request, dst any, _ decodeOptions) error {
+	return xml.NewDecoder(r.Body).Decode(dst)
+}
+
+// decodeValues decodes values with the given schema decoder, whose fields are
+// named by tag. When the call sets the strict mode, unknown keys are rejected
+// or dropped whatever the decoder is configured to do with them
+func (m *Middleware) decodeValues(d *schema.Decoder, tag string, dst any, src Source, values map[string][]string, o decodeOptions) error {
+	if o.strictValues != nil {
+		unknown := unknownKeys(reflect.TypeOf(dst), tag, values)
+		if *o.strictValues && len(unknown) > 0 {
+			return unknownKeysError(src, unknown, values)
+		}
+		if len(unknown) > 0 {
+			known := make(map[string][]string, len(values))
+			for k, v := range values {
+				known[k] = v
+			}
+			for _, k := range unknown {
+				delete(known, k)
+			}
+			values = known
+		}
+	}
+	if err := d.Decode(dst, values); err != nil {
+		return newSchemaError(src, err, values)
+	}
+	return nil
+}
+
+// unknownKeysError reports the keys of values that the schema has no field for
+func unknownKeysError(src Source, unknown []string, values map[string][]string) FieldErrors {
+	fields := make(FieldErrors, 0, len(unknown))
+	for _, k := range unknown {
+		fields = append(fields, FieldError{Source: src, Field: k, Rule: "unknown", Value: valuesOf(values[k]), Message: "is not allowed"})
+	}
+	return fields
+}
+
+// tagValues collects the values named by the given tag of the fields of typ.
+// For headers, lookup is http.Header.Values, so names are matched by their
+// canonical MIME form. Repeated values are kept so that they can be decoded
+// into slices
+func tagValues(typ reflect.Type, tag string, lookup func(name string) []string) map[string][]string {
+	values := make(map[string][]string)
+	for _, f := range reflect.VisibleFields(indirectType(typ)) {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if f.Anonymous || name == "" || name == "-" {
+			continue
+		}
+		if v := lookup(name); len(v) > 0 {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// malformedCookieError reports a Cookie header that could not be parsed
+func malformedCookieError(err error) *Error {
+	return &Error{
+		Kind:   KindDecode,
+		Source: Cookie,
+		Fields: FieldErrors{{Source: Cookie, Rule: "syntax", Message: "malformed Cookie header"}},
+		Err:    err,
+	}
+}
+
+// parseCookies parses every Cookie header of the request. Unlike
+// http.Request.Cookies, it fails on malformed headers instead of skipping them
+func parseCookies(h http.Header) (map[string][]string, error) {
+	cookies := make(map[string][]string)
+	for _, line := range h.Values("Cookie") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parsed, err := http.ParseCookie(line)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range parsed {
+			cookies[c.Name] = append(cookies[c.Name], c.Value)
+		}
+	}
+	return cookies, nil
+}
+
+// replayBody records what is read from a request body
+type replayBody struct {
+	body io.ReadCloser
+	read bytes.Buffer
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.read.Write(p[:n])
+	return n, err
+}
+
+func (b *replayBody) Close() error {
+	return b.body.Close()
+}
+
+// rewind returns a body that yields what was read and then the rest of the
+// original body
+func (b *replayBody) rewind() io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b.read.Bytes()), b.body), b.body}
+}
@@ -0,0 +1,123 @@
+package gv_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Lines is a custom source for bodies of "key: value" lines
+const Lines gv.Source = "Lines"
+
+// decodeLines decodes "key: value" lines by converting them to a JSON object
+func decodeLines(r *http.Request, dst any) error {
+	obj := make(map[string]string)
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		if k, v, ok := strings.Cut(scanner.Text(), ":"); ok {
+			obj[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// LinesSchema defines a schema for the custom source
+type LinesSchema struct {
+	Name  string `lines:"full_name" json:"full_name" validate:"required"`
+	Email string `lines:"e_mail" json:"e_mail" validate:"required,email"`
+}
+
+func TestRegisterSourceOK(t *testing.T) {
+	// Create an instance with the custom source
+	m := gv.New(gv.WithSource(Lines, decodeLines, gv.Tag("lines"), gv.MediaTypes("text/x-lines", "+lines")))
+
+	for _, tc := range []struct {
+		name string
+		src  gv.Source
+		ct   string
+	}{
+		{"Source", Lines, ""},
+		{"BodyMediaType", gv.Body, "text/x-lines; charset=utf-8"},
+		{"BodySuffix", gv.Body, "application/vnd.user+lines"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that will be called after validation succeeds
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data := gv.Validated[*LinesSchema](r, tc.src)
+				assert.Equal(t, "John", data.Name)
+				assert.Equal(t, "john@example.com", data.Email)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Register the route with the validated handler
+			router.Handle("/test", m.Validate(LinesSchema{}, tc.src)(handlerFunc))
+
+			// Create a request with a body of lines
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("full_name: John\ne_mail: john@example.com\n"))
+			req.Header.Set("Content-Type", tc.ct)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify the response
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestRegisterSourceError(t *testing.T) {
+	// Create a body with an invalid email
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("full_name: John\ne_mail: nope\n"))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		m.RegisterSource(Lines, decodeLines, gv.Tag("lines"))
+		return m.Validate(LinesSchema{}, Lines)
+	})
+
+	// Verify that the error uses the source and its tag
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, Lines, err.Fields[0].Source)
+	assert.Equal(t, "e_mail", err.Fields[0].Field)
+}
+
+func TestRegisterSourceIsolated(t *testing.T) {
+	// Register the custom source on an instance only
+	gv.New(gv.WithSource(Lines, decodeLines))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that should never be called because the source is unknown
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "should not reach here")
+	})
+
+	// Apply the default instance middleware that does not know the source
+	router.Handle("/test", gv.Validate(LinesSchema{}, Lines)(handlerFunc))
+
+	// Create a request
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("full_name: John\n"))
+	rr := httptest.NewRecorder()
+
+	// Verify that the default instance panics on the unknown source
+	assert.Panics(t, func() {
+		router.ServeHTTP(rr, req)
+	})
+}
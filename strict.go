@@ -0,0 +1,200 @@
+package gv
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeStrictJSON decodes a JSON body rejecting unknown fields and data after
+// the JSON document. Unknown fields are reported with their paths
+func decodeStrictJSON(r *http.Request, dst any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return unmarshalStrictJSON(data, dst)
+}
+
+// unmarshalStrictJSON decodes a JSON document like decodeStrictJSON
+func unmarshalStrictJSON(data []byte, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			if fields := unknownJSONFields(data, reflect.TypeOf(dst)); len(fields) > 0 {
+				return fields
+			}
+		}
+		return err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return FieldErrors{{Rule: "syntax", Message: "unexpected data after the JSON document"}}
+	}
+	return nil
+}
+
+// unknownJSONFields lists the fields of a JSON document that typ has no field
+// for. Names are matched like encoding/json does, case-insensitively
+func unknownJSONFields(data []byte, typ reflect.Type) FieldErrors {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var fields FieldErrors
+	walkUnknownJSON(doc, typ, "", &fields)
+	return fields
+}
+
+func walkUnknownJSON(v any, t reflect.Type, path string, fields *FieldErrors) {
+	t = indirectType(t)
+	if t == nil || t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		switch t.Kind() {
+		case reflect.Struct:
+			for _, k := range keys {
+				f, ok := jsonField(t, k)
+				if !ok {
+					*fields = append(*fields, FieldError{Field: joinPath(path, k), Rule: "unknown", Message: "is not allowed"})
+					continue
+				}
+				walkUnknownJSON(v[k], f.Type, joinPath(path, k), fields)
+			}
+		case reflect.Map:
+			for _, k := range keys {
+				walkUnknownJSON(v[k], t.Elem(), joinPath(path, k), fields)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				walkUnknownJSON(item, t.Elem(), path+"["+strconv.Itoa(i)+"]", fields)
+			}
+		}
+	}
+}
+
+// jsonField returns the field of the struct t that the JSON key name is
+// decoded into
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		key, _, _ := strings.Cut(tag, ",")
+		if key == "" {
+			if f.Anonymous && indirectType(f.Type).Kind() == reflect.Struct {
+				// Fields of embedded structs are promoted
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			key = f.Name
+		}
+		if key == name {
+			return f, true
+		}
+		if folded == nil && strings.EqualFold(key, name) {
+			folded = &f
+		}
+	}
+	if folded == nil {
+		return reflect.StructField{}, false
+	}
+	return *folded, true
+}
+
+// joinPath appends a key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// unknownKeys lists the keys of values, sorted, that the schema decoder has no
+// field of typ for. Keys are dotted paths named by tag, where slices of
+// structs are indexed like "items.0.price"
+func unknownKeys(typ reflect.Type, tag string, values map[string][]string) []string {
+	var unknown []string
+	for k := range values {
+		if !knownKey(typ, tag, strings.Split(k, ".")) {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// knownKey reports whether the path parts resolve to a field of typ
+func knownKey(typ reflect.Type, tag string, parts []string) bool {
+	t := indirectType(typ)
+	for i := 0; i < len(parts); i++ {
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		f, ok := schemaField(t, tag, parts[i])
+		if !ok {
+			return false
+		}
+		t = indirectType(f.Type)
+		if t.Kind() == reflect.Slice && indirectType(t.Elem()).Kind() == reflect.Struct && i+1 < len(parts) {
+			// Slices of structs are indexed by the next part
+			if _, err := strconv.Atoi(parts[i+1]); err != nil {
+				return false
+			}
+			t = indirectType(t.Elem())
+			i++
+		}
+	}
+	return true
+}
+
+// schemaField finds the field of the struct t named by tag, matching names
+// case-insensitively like gorilla/schema does
+func schemaField(t reflect.Type, tag, name string) (reflect.StructField, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		alias := tagName(f, tag)
+		if alias == "" || f.Tag.Get(tag) == "-" {
+			continue
+		}
+		if strings.EqualFold(alias, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,189 @@
+package gv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+	gv "github.com/iamolegga/gorilla-validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictJSONUnknownFields(t *testing.T) {
+	// Create a JSON body with misspelled top-level and nested fields
+	body := `{"id":1,"emial":"x","profile":{"name":"John","email":"john@example.com","nick":"J"},"follow_ids":[1]}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(TestSchema{}, gv.JSON, gv.Strict(true))
+	})
+
+	// Verify that every unknown field is reported with its path
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, gv.FieldError{Source: gv.JSON, Field: "emial", Rule: "unknown", Message: "is not allowed"}, err.Fields[0])
+	assert.Equal(t, "profile.nick", err.Fields[1].Field)
+}
+
+func TestStrictJSONNestedSlice(t *testing.T) {
+	// Create a JSON body with an unknown field inside a slice element
+	body := `{"customerEmail":"a@example.com","items":[{"price":1},{"price":2,"qty":3}]}`
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(OrderSchema{}, gv.JSON, gv.Strict(true))
+	})
+
+	// Verify the path of the unknown field
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "items[1].qty", err.Fields[0].Field)
+}
+
+func TestStrictJSONTrailingData(t *testing.T) {
+	for name, body := range map[string]string{
+		"Document": `{"id":1,"profile":{"name":"John","email":"john@example.com"}}{"id":2}`,
+		"Garbage":  `{"id":1,"profile":{"name":"John","email":"john@example.com"}} garbage`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+
+			err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+				return m.Validate(TestSchema{}, gv.JSON, gv.Strict(true))
+			})
+
+			// Verify that the trailing data is rejected
+			assert.Equal(t, gv.KindDecode, err.Kind)
+			require.Len(t, err.Fields, 1)
+			assert.Equal(t, "syntax", err.Fields[0].Rule)
+		})
+	}
+}
+
+func TestStrictJSONDefault(t *testing.T) {
+	// Create an instance that is strict by default
+	m := gv.New(gv.WithStrictJSON(true))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called when decoding succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register a strict route and a route that opts out
+	router.Handle("/strict", m.Validate(TestSchema{}, gv.JSON)(handlerFunc))
+	router.Handle("/lenient", m.Validate(TestSchema{}, gv.JSON, gv.Strict(false))(handlerFunc))
+
+	body := `{"id":1,"emial":"x","profile":{"name":"John","email":"john@example.com"}}`
+	for path, code := range map[string]int{
+		"/strict":  http.StatusBadRequest,
+		"/lenient": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		// Let the router handle the request
+		router.ServeHTTP(rr, req)
+
+		// Verify the response
+		assert.Equal(t, code, rr.Code, path)
+	}
+}
+
+func TestStrictQueryUnknownKeys(t *testing.T) {
+	// Create an instance whose decoder ignores unknown keys
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+
+	// Create a request with two misspelled filters and an indexed nested key
+	req := httptest.NewRequest(http.MethodGet, "/test?customer_email=a@example.com&items.0.price=1&sort_by=date&items.0.qty=2", nil)
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(OrderSchema{}, gv.Query, gv.Strict(true))
+	}, gv.WithSchemaDecoder(decoder))
+
+	// Verify that every unexpected parameter is listed
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 2)
+	assert.Equal(t, gv.FieldError{Source: gv.Query, Field: "items.0.qty", Rule: "unknown", Value: "2", Message: "is not allowed"}, err.Fields[0])
+	assert.Equal(t, "sort_by", err.Fields[1].Field)
+}
+
+func TestStrictQueryLenient(t *testing.T) {
+	// Create an instance with the default decoder, which rejects unknown keys
+	m := gv.New()
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called when decoding succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*ParamsTestSchema](r, gv.Query)
+		assert.Equal(t, 1, data.ID)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register a route that uses the decoder setting and a route that ignores unknown keys
+	router.Handle("/default", m.Validate(ParamsTestSchema{}, gv.Query)(handlerFunc))
+	router.Handle("/lenient", m.Validate(ParamsTestSchema{}, gv.Query, gv.Strict(false))(handlerFunc))
+
+	for path, code := range map[string]int{
+		"/default": http.StatusBadRequest,
+		"/lenient": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path+"?id=1&utm_source=mail", nil)
+		rr := httptest.NewRecorder()
+
+		// Let the router handle the request
+		router.ServeHTTP(rr, req)
+
+		// Verify the response
+		assert.Equal(t, code, rr.Code, path)
+	}
+}
+
+func TestStrictHeaderAndCookie(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handlers that will be called after validation succeeds
+	headerHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*HeaderTestSchema](r, gv.Header)
+		assert.Equal(t, "acme", data.Tenant)
+		w.WriteHeader(http.StatusOK)
+	})
+	cookieHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*CookieTestSchema](r, gv.Cookie)
+		assert.Equal(t, "abc123", data.Session)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register strict routes, fields are named by the header and cookie tags
+	router.Handle("/header", gv.Validate(HeaderTestSchema{}, gv.Header, gv.Strict(true))(headerHandler))
+	router.Handle("/cookie", gv.Validate(CookieTestSchema{}, gv.Cookie, gv.Strict(true))(cookieHandler))
+
+	// Create requests with valid headers and cookies, and others that are
+	// not read by the schemas
+	req := httptest.NewRequest(http.MethodGet, "/header", nil)
+	req.Header.Set("X-Request-ID", "123e4567-e89b-12d3-a456-426614174000")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("User-Agent", "test")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/cookie", nil)
+	req.Header.Set("Cookie", "session_id=abc123; theme=dark")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
@@ -3,35 +3,50 @@ package gv
 import (
 	"context"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"net/http"
 	"reflect"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 )
 
-// ErrorHandlerFunc is a function type that defines how validation errors are handled
+// ErrorHandlerFunc is a function type that defines how validation errors are handled.
+// The error passed to it is always an *Error
 type ErrorHandlerFunc func(err error) http.HandlerFunc
 
 // defaultErrorHandler is the default implementation of error handling
-var currentErrorHandler = func(err error) http.HandlerFunc {
+func defaultErrorHandler(err error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		status := http.StatusBadRequest
+		msg := err.Error()
+		var e *Error
+		if errors.As(err, &e) {
+			status = e.Status()
+			// Errors of the loader are not errors of the request, their
+			// cause is not shown to clients
+			if e.Kind == KindLoad || e.Kind == KindNotFound {
+				msg = http.StatusText(status)
+			}
+		}
+		http.Error(w, msg, status)
 	}
 }
 
-// ErrorHandler allows setting a custom error handler function
-func ErrorHandler(h ErrorHandlerFunc) {
-	currentErrorHandler = h
-}
-
 // sourceKey is a type for context keys used to store validated data. It is
 // used to avoid conflicts with other middleware that may use the same context
-// keys
+// keys. It holds the data of the last schema validated for the source
 type sourceKey string
 
+// schemaKey is the context key of the data of a schema validated for a
+// source, so that several schemas of one source are stored side by side
+type schemaKey struct {
+	src Source
+	typ reflect.Type
+}
+
 // Source represents the source of input data for validation
 type Source string
 
@@ -41,80 +56,348 @@ const (
 	Form   Source = "Form"
 	JSON   Source = "JSON"
 	XML    Source = "XML"
+	Header Source = "Header"
+	Cookie Source = "Cookie"
+	// Body decodes the request body as JSON, XML or Form depending on its
+	// Content-Type
+	Body Source = "Body"
 )
 
-// SchemaDecoder is an instance of the schema decoder from the gorilla/schema package, could be used for setting custom options
+// SchemaDecoder is an instance of the schema decoder from the gorilla/schema package, could be used for setting custom options.
+// It is used by the package-level Validate function; instances created with New have their own decoder
 var SchemaDecoder = schema.NewDecoder()
 
-var currentValidator = validator.New()
+// Middleware carries its own validator, schema decoder and error handler, so
+// several independently configured instances can live in one binary. Use New
+// to create one
+type Middleware struct {
+	validator     *validator.Validate
+	decoder       *schema.Decoder
+	headerDecoder *schema.Decoder
+	cookieDecoder *schema.Decoder
+	errorHandler  ErrorHandlerFunc
+	// multipartMemory is the maxMemory argument of ParseMultipartForm
+	multipartMemory int64
+	// maxBodySize is the default limit of the request body, 0 means no limit
+	maxBodySize int64
+	// strictJSON rejects unknown fields and trailing data of JSON bodies
+	strictJSON bool
+	// sources holds the registered sources
+	sources map[Source]*source
+	// mediaTypes maps media types and structured syntax suffixes to the
+	// sources that decode them for the Body source
+	mediaTypes map[string]Source
+	// scenarios holds the validators of the validate.<name> tags
+	scenarios map[string]*validator.Validate
+	// optionals holds the Optional types registered on the validators
+	optionals map[reflect.Type]bool
+	// mu guards the scenarios, the optionals and the validators they are
+	// registered on
+	mu sync.Mutex
+}
+
+// Option configures a Middleware
+type Option func(*Middleware)
+
+// WithValidator sets the validator instance used by the Middleware
+func WithValidator(v *validator.Validate) Option {
+	return func(m *Middleware) {
+		m.validator = v
+	}
+}
+
+// WithSchemaDecoder sets the schema decoder used for the Params, Query and Form sources.
+// Field paths of errors and the Strict option read the schema tag, so keep the
+// default alias tag of the decoder
+func WithSchemaDecoder(d *schema.Decoder) Option {
+	return func(m *Middleware) {
+		m.decoder = d
+	}
+}
+
+// WithMultipartMemory sets the number of bytes of multipart/form-data bodies
+// that are kept in memory, the rest of the file parts is stored on disk
+func WithMultipartMemory(n int64) Option {
+	return func(m *Middleware) {
+		m.multipartMemory = n
+	}
+}
 
-// Validator allows setting a custom validator instance
+// WithMaxBodySize sets the default number of bytes of the request body that
+// Validate reads, larger bodies are rejected with HTTP 413 (Content Too
+// Large). 0 means no limit. Validate calls can override it with LimitBody
+func WithMaxBodySize(n int64) Option {
+	return func(m *Middleware) {
+		m.maxBodySize = n
+	}
+}
+
+// WithStrictJSON makes JSON decoding reject unknown fields and data after the
+// JSON document by default. Validate calls can override it with Strict
+func WithStrictJSON(strict bool) Option {
+	return func(m *Middleware) {
+		m.strictJSON = strict
+	}
+}
+
+// WithErrorHandler sets the function that handles validation errors
+func WithErrorHandler(h ErrorHandlerFunc) Option {
+	return func(m *Middleware) {
+		m.errorHandler = h
+	}
+}
+
+// New creates a Middleware with a fresh validator, schema decoder and the
+// default error handler, then applies the given options. The file rules
+// filesize and filetype are registered on the validator
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		validator:       validator.New(),
+		decoder:         schema.NewDecoder(),
+		headerDecoder:   newTagDecoder("header"),
+		cookieDecoder:   newTagDecoder("cookie"),
+		errorHandler:    defaultErrorHandler,
+		multipartMemory: DefaultMultipartMemory,
+		scenarios:       make(map[string]*validator.Validate),
+		optionals:       make(map[reflect.Type]bool),
+	}
+	for _, t := range builtinOptionals {
+		m.optionals[t] = true
+	}
+	m.registerBuiltinSources()
+	for _, opt := range opts {
+		opt(m)
+	}
+	registerFileValidations(m.validator)
+	registerOptionalTypes(m.validator, m.knownOptionals())
+	return m
+}
+
+// std is the default instance behind the package-level functions
+var std = newStd()
+
+// newStd creates the default instance. Its decoder is left nil so that it
+// always resolves to the current SchemaDecoder
+func newStd() *Middleware {
+	m := New()
+	m.decoder = nil
+	return m
+}
+
+// newTagDecoder creates the schema decoder used for the Header and Cookie
+// sources, which read field names from the given tag
+func newTagDecoder(tag string) *schema.Decoder {
+	d := schema.NewDecoder()
+	d.SetAliasTag(tag)
+	d.IgnoreUnknownKeys(true)
+	return d
+}
+
+// ErrorHandler allows setting a custom error handler function for the default instance
+func ErrorHandler(h ErrorHandlerFunc) {
+	std.errorHandler = h
+}
+
+// Validator allows setting a custom validator instance for the default instance.
+// The file rules filesize and filetype are registered on it
 func Validator(v *validator.Validate) {
-	currentValidator = v
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	registerFileValidations(v)
+	registerOptionalTypes(v, std.knownOptionals())
+	std.validator = v
+}
+
+// MultipartMemory sets the number of bytes of multipart/form-data bodies that
+// the default instance keeps in memory
+func MultipartMemory(n int64) {
+	std.multipartMemory = n
+}
+
+// MaxBodySize sets the default number of bytes of the request body that the
+// default instance reads. 0 means no limit
+func MaxBodySize(n int64) {
+	std.maxBodySize = n
+}
+
+// StrictJSON makes JSON decoding of the default instance reject unknown
+// fields and data after the JSON document
+func StrictJSON(strict bool) {
+	std.strictJSON = strict
+}
+
+// Validate is a middleware factory function that validates the input data
+// based on the provided schema and source using the default instance
+func Validate(schema any, src Source, opts ...ValidateOption) mux.MiddlewareFunc {
+	return std.Validate(schema, src, opts...)
+}
+
+// schemaDecoder returns the decoder of the instance, falling back to the
+// package-level SchemaDecoder for the default instance
+func (m *Middleware) schemaDecoder() *schema.Decoder {
+	if m.decoder == nil {
+		return SchemaDecoder
+	}
+	return m.decoder
 }
 
 // Validate is a middleware factory function that validates the input data based on the provided schema and source
-func Validate(schema any, src Source) mux.MiddlewareFunc {
+func (m *Middleware) Validate(schema any, src Source, opts ...ValidateOption) mux.MiddlewareFunc {
+	var cfg validateConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	typ := reflect.TypeOf(schema)
+	m.prepareSchema(typ, src, &cfg)
+
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			schemaValue := reflect.New(reflect.TypeOf(schema)).Interface()
-
-			switch src {
-			case Params:
-				vars := mux.Vars(r)
-				varsFixed := make(map[string][]string)
-				for k, v := range vars {
-					varsFixed[k] = []string{v}
-				}
-				err := SchemaDecoder.Decode(schemaValue, varsFixed)
-				if err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-			case Query:
-				err := SchemaDecoder.Decode(schemaValue, r.URL.Query())
-				if err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-			case Form:
-				err := r.ParseForm()
-				if err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-				err = SchemaDecoder.Decode(schemaValue, r.PostForm)
-				if err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-			case JSON:
-				if err := json.NewDecoder(r.Body).Decode(schemaValue); err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-			case XML:
-				if err := xml.NewDecoder(r.Body).Decode(schemaValue); err != nil {
-					currentErrorHandler(err).ServeHTTP(w, r)
-					return
-				}
-			default:
-				panic("unknown source: " + src)
+			parsed := r.MultipartForm != nil
+			r, err := m.validate(w, r, typ, src, &cfg)
+			// net/http only removes the files of the multipart form of its
+			// own request, not of the copies the middleware parses
+			if !parsed && r.MultipartForm != nil {
+				defer r.MultipartForm.RemoveAll()
 			}
-
-			err := currentValidator.Struct(schemaValue)
 			if err != nil {
-				currentErrorHandler(err).ServeHTTP(w, r)
+				m.errorHandler(err).ServeHTTP(w, r)
 				return
 			}
-
-			r = r.WithContext(context.WithValue(r.Context(), sourceKey(src), schemaValue))
 			handler.ServeHTTP(w, r)
 		})
 	}
 }
 
-// Validated is a function that returns the validated data from the request context
+// prepareSchema checks the default and mod tags of the schema type, and the in
+// tags of Request schemas, panicking when they are invalid, and registers its
+// Optional fields on the validators of the call
+func (m *Middleware) prepareSchema(typ reflect.Type, src Source, cfg *validateConfig) {
+	checkDefaults(typ)
+	checkModifiers(typ)
+	if src == Request {
+		inFields(indirectType(typ))
+	}
+	if cfg.scenario != "" {
+		cfg.scenarioValidator = m.scenarioValidator(cfg.scenario)
+		cfg.scoped = scopedFields(typ, scenarioTag(cfg.scenario))
+	}
+	m.registerOptionals(typ)
+}
+
+// validate decodes the source into a new value of typ and validates it. It
+// returns the request carrying the validated data in its context
+func (m *Middleware) validate(w http.ResponseWriter, r *http.Request, typ reflect.Type, src Source, cfg *validateConfig) (*http.Request, *Error) {
+	schemaValue := reflect.New(typ).Interface()
+
+	// The Body source is decoded by the source of the Content-Type,
+	// errors are reported with that source
+	from := src
+	if src == Body {
+		var ok bool
+		if from, ok = m.bodySource(r); !ok {
+			return r, newMediaTypeError(r)
+		}
+	}
+	s, ok := m.sources[from]
+	if !ok {
+		panic("unknown source: " + from)
+	}
+
+	limit := cfg.bodyLimit(m)
+	if limit > 0 && r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	// Keep what the decoder reads, so that the body can be decoded
+	// again by the next middleware or read by the handler
+	var replay *replayBody
+	if !s.cachesBody && r.Body != nil && r.Body != http.NoBody {
+		replay = &replayBody{body: r.Body}
+		r.Body = replay
+	}
+	err := s.decode(r, schemaValue, cfg.decodeOptions(m))
+	var raw []byte
+	if replay != nil {
+		raw = replay.read.Bytes()
+		r.Body = replay.rewind()
+	}
+	if err != nil {
+		return r, newDecodeError(from, err)
+	}
+	// The fields present in JSON bodies are resolved when they are needed
+	var present *presence
+	if from == JSON {
+		present = newPresence(raw, typ)
+	}
+	// Normalize the strings and fill the fields the client left out before
+	// the hooks and the rules
+	applyModifiers(reflect.ValueOf(schemaValue))
+	applyDefaults(reflect.ValueOf(schemaValue))
+
+	vctx := validationContext(r)
+	if err := afterDecode(vctx, schemaValue); err != nil {
+		return r, newDecodeError(from, err)
+	}
+	if err := beforeValidate(vctx, schemaValue); err != nil {
+		return r, newError(KindValidation, from, err)
+	}
+
+	var errs []*Error
+	if err := m.applyRules(vctx, schemaValue, typ, cfg, present); err != nil {
+		// Field paths use the wire names of the source instead of the Go
+		// field names of the schema
+		fieldPath := func(typ reflect.Type, ns string) (Source, string) {
+			return from, wirePath(typ, ns, s.tag)
+		}
+		if s.fieldPath != nil {
+			fieldPath = func(typ reflect.Type, ns string) (Source, string) {
+				return s.fieldPath(r, raw, typ, ns)
+			}
+		}
+		errs = append(errs, newValidationError(from, err, schemaValue, fieldPath))
+	}
+	// The Validate method of the schema adds its errors to the ones of the rules
+	if err := validateSchema(vctx, schemaValue); err != nil {
+		errs = append(errs, newError(KindValidation, from, err))
+	}
+	if len(errs) > 0 {
+		return r, joinErrors(errs)
+	}
+
+	ctx := context.WithValue(r.Context(), sourceKey(src), schemaValue)
+	ctx = context.WithValue(ctx, schemaKey{src, reflect.TypeOf(schemaValue)}, schemaValue)
+	if cfg.key != nil {
+		ctx = context.WithValue(ctx, cfg.key, schemaValue)
+	}
+	if present != nil {
+		ctx = context.WithValue(ctx, presenceKey(src), present)
+	}
+	if from == MergePatch || from == JSONPatch {
+		ctx = context.WithValue(ctx, patchKey(src), json.RawMessage(raw))
+	}
+	return r.WithContext(ctx), nil
+}
+
+// applyRules applies the validation rules of the call to the schema
+func (m *Middleware) applyRules(ctx context.Context, schemaValue any, typ reflect.Type, cfg *validateConfig, present *presence) error {
+	switch {
+	case cfg.scenario != "":
+		var fields []string
+		if cfg.partial && present != nil {
+			fields = present.namespaces()
+		}
+		return m.validateScenario(ctx, schemaValue, typ, cfg, fields)
+	case cfg.partial && present != nil:
+		// Only the fields present in the body are validated
+		return m.validator.StructPartialCtx(ctx, schemaValue, present.namespaces()...)
+	default:
+		return m.validator.StructCtx(ctx, schemaValue)
+	}
+}
+
+// Validated is a function that returns the validated data from the request
+// context. It works for data stored by any Middleware instance. It panics like
+// MustValidated when there is no data of type T
 func Validated[T any](r *http.Request, src Source) T {
-	return r.Context().Value(sourceKey(src)).(T)
+	return MustValidated[T](r, src)
 }
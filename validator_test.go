@@ -1,6 +1,7 @@
 package gv_test
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 	"github.com/gorilla/mux"
 	gv "github.com/iamolegga/gorilla-validator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type Profile struct {
@@ -602,3 +604,283 @@ func TestCustomValidator(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 }
+
+func TestInstancesAreIsolated(t *testing.T) {
+	// Create two instances with different error handlers
+	teapot := gv.New(gv.WithErrorHandler(func(err error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	conflict := gv.New(gv.WithErrorHandler(func(err error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+
+	for _, tc := range []struct {
+		name string
+		m    *gv.Middleware
+		code int
+	}{
+		{"Teapot", teapot, http.StatusTeapot},
+		{"Conflict", conflict, http.StatusConflict},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a new router
+			router := mux.NewRouter()
+
+			// Define the handler that should never be called because validation will fail
+			handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Fail(t, "should not reach here")
+			})
+
+			// Apply the instance middleware to the handler
+			router.Handle("/test/{id}", tc.m.Validate(ParamsTestSchema{}, gv.Params)(handlerFunc))
+
+			// Create a request with an invalid ID (abc is not an integer)
+			req := httptest.NewRequest(http.MethodGet, "/test/abc", nil)
+			rr := httptest.NewRecorder()
+
+			// Let the router handle the request
+			router.ServeHTTP(rr, req)
+
+			// Verify that the instance's own error handler was used
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+}
+
+func TestInstanceCustomValidator(t *testing.T) {
+	// Create a validator with a custom rule that only this instance uses
+	v := validator.New()
+	v.RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	})
+	m := gv.New(gv.WithValidator(v))
+
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*CustomValidationSchema](r, gv.Params)
+		assert.Equal(t, 4, data.ID)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the instance middleware
+	router.Handle("/test/{id}", m.Validate(CustomValidationSchema{}, gv.Params)(handlerFunc))
+
+	// Create a request with an even ID
+	req := httptest.NewRequest(http.MethodGet, "/test/4", nil)
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// HeaderTestSchema defines a schema for request headers
+type HeaderTestSchema struct {
+	RequestID string   `header:"X-Request-ID" validate:"required,uuid"`
+	Tenant    string   `header:"x-tenant" validate:"required"`
+	Version   int      `header:"Api-Version" validate:"omitempty,gte=1"`
+	Forwarded []string `header:"X-Forwarded-For"`
+}
+
+func TestValidateHeaderOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*HeaderTestSchema](r, gv.Header)
+		assert.Equal(t, "0b6f2c4e-7f0a-4a43-9d53-5e3c7e1a2b10", data.RequestID)
+		assert.Equal(t, "acme", data.Tenant)
+		assert.Equal(t, 2, data.Version)
+		assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, data.Forwarded)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(HeaderTestSchema{}, gv.Header)(handlerFunc))
+
+	// Create a request with headers in non-canonical case and a repeated header
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("x-request-id", "0b6f2c4e-7f0a-4a43-9d53-5e3c7e1a2b10")
+	req.Header.Set("X-TENANT", "acme")
+	req.Header.Set("Api-Version", "2")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	req.Header.Add("X-Forwarded-For", "10.0.0.2")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateHeaderError(t *testing.T) {
+	// Create a request without the tenant and with a non-integer version
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Request-ID", "0b6f2c4e-7f0a-4a43-9d53-5e3c7e1a2b10")
+	req.Header.Set("Api-Version", "two")
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(HeaderTestSchema{}, gv.Header)
+	})
+
+	// Verify that the error names the header
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, gv.Header, err.Fields[0].Source)
+	assert.Equal(t, "Api-Version", err.Fields[0].Field)
+	assert.Equal(t, "two", err.Fields[0].Value)
+
+	// Fix the version so that validation reports the missing tenant
+	req = httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Request-ID", "0b6f2c4e-7f0a-4a43-9d53-5e3c7e1a2b10")
+
+	err = captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(HeaderTestSchema{}, gv.Header)
+	})
+
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "x-tenant", err.Fields[0].Field)
+}
+
+// CookieTestSchema defines a schema for cookies
+type CookieTestSchema struct {
+	Session string `cookie:"session_id" validate:"required"`
+	Locale  string `cookie:"locale" validate:"omitempty,oneof=en de fr"`
+	Consent bool   `cookie:"consent"`
+}
+
+func TestValidateCookieOK(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := gv.Validated[*CookieTestSchema](r, gv.Cookie)
+		assert.Equal(t, "abc123", data.Session)
+		assert.Equal(t, "de", data.Locale)
+		assert.True(t, data.Consent)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Register the route with the validated handler
+	router.Handle("/test", gv.Validate(CookieTestSchema{}, gv.Cookie)(handlerFunc))
+
+	// Create a request with cookies
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Cookie", "session_id=abc123; locale=de; consent=true")
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestValidateCookieMissing(t *testing.T) {
+	// Create a request without the session cookie
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Cookie", "locale=en")
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(CookieTestSchema{}, gv.Cookie)
+	})
+
+	// Verify that the missing cookie fails validation like a missing query key
+	assert.Equal(t, gv.KindValidation, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, "session_id", err.Fields[0].Field)
+	assert.Equal(t, "required", err.Fields[0].Rule)
+}
+
+func TestValidateCookieMalformed(t *testing.T) {
+	// Create a request with a malformed cookie header
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Cookie", "session_id=abc123; broken")
+
+	err := captureError(t, "/test", req, func(m *gv.Middleware) mux.MiddlewareFunc {
+		return m.Validate(CookieTestSchema{}, gv.Cookie)
+	})
+
+	// Verify that the header is rejected instead of skipped
+	assert.Equal(t, gv.KindDecode, err.Kind)
+	require.Len(t, err.Fields, 1)
+	assert.Equal(t, gv.Cookie, err.Fields[0].Source)
+	assert.Equal(t, "syntax", err.Fields[0].Rule)
+}
+
+// Pagination and Filters are two schemas decoded from the same query
+type Pagination struct {
+	Page  int `schema:"page" validate:"omitempty,gte=1"`
+	Limit int `schema:"limit" validate:"omitempty,lte=100"`
+}
+
+type Filters struct {
+	Status string `schema:"status" validate:"omitempty,oneof=active blocked"`
+}
+
+// Audit is a second schema decoded from the JSON body of TestSchema
+type Audit struct {
+	ID     int    `json:"id" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
+}
+
+func TestValidateSeveralSchemasOfSource(t *testing.T) {
+	// Create a new router
+	router := mux.NewRouter()
+
+	body := `{"id":123,"reason":"import","profile":{"name":"John","email":"john@example.com"}}`
+
+	// Define the handler that will be called after validation succeeds
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pagination := gv.Validated[*Pagination](r, gv.Query)
+		assert.Equal(t, 2, pagination.Page)
+		filters := gv.Validated[*Filters](r, gv.Query)
+		assert.Equal(t, "active", filters.Status)
+
+		data := gv.Validated[*TestSchema](r, gv.JSON)
+		assert.Equal(t, "John", data.Profile.Name)
+		audit := gv.Validated[*Audit](r, gv.JSON)
+		assert.Equal(t, "import", audit.Reason)
+
+		// Verify that the body can still be read by the handler
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(raw))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Apply two schemas for the query and two schemas for the body
+	lenient := gv.Strict(false)
+	validatedHandler := gv.Validate(Pagination{}, gv.Query, lenient)(
+		gv.Validate(Filters{}, gv.Query, lenient)(
+			gv.Validate(TestSchema{}, gv.JSON)(
+				gv.Validate(Audit{}, gv.JSON)(handlerFunc))))
+
+	// Register the route with the validated handler
+	router.Handle("/test", validatedHandler).Methods(http.MethodPost)
+
+	// Create a request with a query and a JSON body
+	req := httptest.NewRequest(http.MethodPost, "/test?page=2&status=active", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	// Let the router handle the request
+	router.ServeHTTP(rr, req)
+
+	// Verify the response
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,58 @@
+package gv
+
+import "reflect"
+
+// walkValues calls fn with v and every value reachable from it through
+// pointers, struct fields and the elements of slices, arrays and maps. Map
+// values are walked on a copy that is stored back
+func walkValues(v reflect.Value, fn func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walkValues(v.Elem(), fn)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			walkValues(v.Index(i), fn)
+		}
+		return
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			walkValues(elem, fn)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+		return
+	}
+	fn(v)
+	if v.Kind() == reflect.Struct {
+		for i := range v.NumField() {
+			if v.Field(i).CanSet() {
+				walkValues(v.Field(i), fn)
+			}
+		}
+	}
+}
+
+// walkFields calls fn with every field of typ and of the struct types nested
+// in it through pointers, slices, arrays and maps
+func walkFields(typ reflect.Type, fn func(reflect.StructField)) {
+	walkFieldsOf(typ, fn, make(map[reflect.Type]bool))
+}
+
+func walkFieldsOf(typ reflect.Type, fn func(reflect.StructField), seen map[reflect.Type]bool) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || seen[typ] {
+		return
+	}
+	seen[typ] = true
+	for i := range typ.NumField() {
+		fn(typ.Field(i))
+		walkFieldsOf(typ.Field(i).Type, fn, seen)
+	}
+}